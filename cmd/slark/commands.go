@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"slark/internal/config"
+)
+
+// Exit codes shared by all commands
+const (
+	exitOK    = 0 // The command succeeded
+	exitError = 1 // The command failed
+	exitUsage = 2 // The command was called with invalid arguments
+)
+
+// Output formats shared by all commands
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// outputFormat is the output format of the running command
+var outputFormat = outputText
+
+// command is a slark subcommand
+type command struct {
+	name    string
+	args    string // Positional arguments shown in the usage line
+	summary string
+	run     func(args []string) int
+}
+
+// commandList returns every subcommand in the order shown in help
+func commandList() []command {
+	return []command{
+		{name: "init", summary: "Set up a CI/CD pipeline, interactively unless flags are given", run: runInit},
+		{name: "update", summary: "Regenerate slark workflows and merge in local edits", run: runUpdate},
+		{name: "status", summary: "List the pipelines configured in the project", run: runStatus},
+		{name: "remove", summary: "Remove a pipeline and optionally its platform project", run: runRemove},
+		{name: "restore", args: "[backup | latest]", summary: "List workflow backups or restore one of them", run: runRestore},
+		{name: "doctor", summary: "Check that the project and credentials are ready for slark", run: runDoctor},
+		{name: "templates", args: "list | show <name> | new <name> | render <name>", summary: "List, show, create or preview workflow templates", run: runTemplates},
+		{name: "version", summary: "Print the slark version", run: runVersion},
+		{name: "help", args: "[command]", summary: "Show help for slark or a command", run: runHelp},
+	}
+}
+
+// findCommand returns the subcommand with the given name
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commandList() {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// newFlagSet creates the flag set of a subcommand with consistent help output
+func newFlagSet(name string) *flag.FlagSet {
+	cmd, _ := findCommand(name)
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&outputFormat, "output", outputText, "Output format: text or json")
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s\n\n%s\n", strings.TrimSpace("slark "+cmd.name+" [flags] "+cmd.args), cmd.summary)
+
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintf(out, "\nFlags:\n")
+			fs.PrintDefaults()
+		}
+	}
+
+	return fs
+}
+
+// parseFlags parses the arguments of a subcommand, allowing flags to follow
+// positional arguments. When parsing stops it returns false along with the
+// exit code to use.
+func parseFlags(fs *flag.FlagSet, args []string) (bool, int) {
+	var positional []string
+	for {
+		err := fs.Parse(args)
+		if errors.Is(err, flag.ErrHelp) {
+			return false, exitOK
+		}
+		if err != nil {
+			return false, exitUsage
+		}
+
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	// Leave only the positional arguments in fs.Args
+	fs.Parse(append([]string{"--"}, positional...))
+
+	if outputFormat != outputText && outputFormat != outputJSON {
+		format := outputFormat
+		outputFormat = outputText
+		return false, usageError("unknown output format %q, expected text or json", format)
+	}
+
+	return true, exitOK
+}
+
+// keyValueFlag collects repeated key=value flags
+type keyValueFlag map[string]string
+
+func (f keyValueFlag) String() string {
+	return ""
+}
+
+func (f keyValueFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	f[key] = val
+	return nil
+}
+
+// mergeValues adds the template variables of the values file and then those
+// given with --set to vars
+func mergeValues(vars map[string]string, valuesFile string, set keyValueFlag) error {
+	if valuesFile != "" {
+		values, err := config.LoadValues(valuesFile)
+		if err != nil {
+			return err
+		}
+		maps.Copy(vars, values)
+	}
+
+	maps.Copy(vars, set)
+	return nil
+}
+
+// printOutput prints v as JSON when JSON output was requested and the human
+// readable text otherwise
+func printOutput(v any, text string) int {
+	if outputFormat != outputJSON {
+		fmt.Print(text)
+		return exitOK
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fail(err)
+	}
+
+	return exitOK
+}
+
+// reportError prints an error on stderr, and as JSON on stdout when JSON
+// output was requested so callers always get a parsable document
+func reportError(message string) {
+	fmt.Fprintln(os.Stderr, "error:", message)
+
+	if outputFormat == outputJSON {
+		json.NewEncoder(os.Stdout).Encode(map[string]string{"error": message})
+	}
+}
+
+// fail reports an error and returns the failure exit code
+func fail(err error) int {
+	reportError(err.Error())
+	return exitError
+}
+
+// usageError reports invalid usage and returns the usage exit code
+func usageError(format string, a ...any) int {
+	reportError(fmt.Sprintf(format, a...))
+	return exitUsage
+}
+
+// printUsage prints the top level help
+func printUsage() {
+	out := flag.CommandLine.Output()
+
+	fmt.Fprintf(out, "Usage: slark [global flags] <command> [flags]\n\n")
+	fmt.Fprintf(out, "Running slark without a command starts the interactive setup.\n\n")
+	fmt.Fprintf(out, "Commands:\n")
+	for _, cmd := range commandList() {
+		fmt.Fprintf(out, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+
+	fmt.Fprintf(out, "\nGlobal flags:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(out, "\nRun 'slark help <command>' for the flags of a command.\n")
+}
+
+// runHelp prints the top level help or the help of a single command
+func runHelp(args []string) int {
+	fs := newFlagSet("help")
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	if fs.NArg() == 0 {
+		printUsage()
+		return exitOK
+	}
+
+	cmd, ok := findCommand(fs.Arg(0))
+	if !ok {
+		return usageError("unknown command %q, known commands: %s", fs.Arg(0), commandNames())
+	}
+
+	// Every command prints its own help when asked for it
+	return cmd.run([]string{"-h"})
+}
+
+// commandNames returns the names of all subcommands
+func commandNames() string {
+	var names []string
+	for _, cmd := range commandList() {
+		names = append(names, cmd.name)
+	}
+	return strings.Join(names, ", ")
+}
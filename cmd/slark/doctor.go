@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+
+	"slark/internal/config"
+	"slark/internal/core"
+	"slark/internal/models"
+)
+
+// runDoctor runs the pre-flight diagnostics and prints a pass/warn/fail table.
+// It fails when any check fails.
+func runDoctor(args []string) int {
+	fs := newFlagSet("doctor")
+	projectPath := fs.String("project-path", ".", "Path to the project to check")
+	vercelTeamID := fs.String("vercel-team-id", "", "Vercel team ID to verify (defaults to the one in .slark.yaml)")
+	vercelToken := fs.String("vercel-token", "", "Vercel API token to verify (defaults to $VERCEL_TOKEN)")
+
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		return usageError("doctor takes no arguments, got %q", fs.Args())
+	}
+
+	root, err := core.ResolveProjectPath(*projectPath)
+	if err != nil {
+		return fail(err)
+	}
+
+	if *vercelToken == "" {
+		*vercelToken = os.Getenv("VERCEL_TOKEN")
+	}
+
+	// Verify the saved team when none is given, broken config is reported by the checks
+	if *vercelTeamID == "" {
+		if cfg, err := config.Load(root); err == nil {
+			if project, ok := cfg.Lookup("", ""); ok {
+				*vercelTeamID = project.TeamId
+			}
+		}
+	}
+
+	checks := core.RunDoctor(root, models.PlatformData{
+		ApiKey: *vercelToken,
+		TeamId: *vercelTeamID,
+	})
+	healthy := true
+	for _, check := range checks {
+		if check.Status == core.CheckFail {
+			healthy = false
+		}
+	}
+
+	output := struct {
+		Healthy bool           `json:"healthy"`
+		Checks  []models.Check `json:"checks"`
+	}{Healthy: healthy, Checks: checks}
+
+	if code := printOutput(output, core.FormatChecks(checks)); code != exitOK || !healthy {
+		return exitError
+	}
+
+	return exitOK
+}
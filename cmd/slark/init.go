@@ -0,0 +1,406 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"slark/internal/config"
+	"slark/internal/core"
+	"slark/internal/models"
+)
+
+// runInit configures a pipeline, or one per app of a monorepo with --all or
+// --discover. In a terminal without configuration flags it starts the
+// interactive form, otherwise it runs entirely from flags.
+// It returns the process exit code.
+func runInit(args []string) int {
+	fs := newFlagSet("init")
+	projectPath := fs.String("project-path", ".", "Path to the project to configure")
+	platform := fs.String("platform", "vercel", "Deployment platform to use (vercel, cloudflare)")
+	projectName := fs.String("project-name", "", "Name of the project (defaults to directory name)")
+	deployBranch := fs.String("deploy-branch", "main", "Branch to track for deployment")
+	buildFolder := fs.String("build-folder", "./", "Folder containing the project to deploy")
+	templateName := fs.String("template", core.DefaultTemplate, "Workflow template variant to use (basic, advanced)")
+	valuesFile := fs.String("values", "", "YAML file of template variables")
+	setValues := keyValueFlag{}
+	fs.Var(setValues, "set", "Set a template variable as `key=value`, can be repeated")
+	framework := fs.String("framework", "", "Framework preset for the Vercel project (e.g. nextjs, vite)")
+	vercelTeamID := fs.String("vercel-team-id", "", "Vercel team ID the project is created in")
+	vercelToken := fs.String("vercel-token", "", "Vercel API token (defaults to $VERCEL_TOKEN)")
+	cloudflareAccountID := fs.String("cloudflare-account-id", "", "Cloudflare account ID the Pages project is created in")
+	cloudflareToken := fs.String("cloudflare-token", "", "Cloudflare API token (defaults to $CLOUDFLARE_API_TOKEN)")
+	telegramChatID := fs.String("telegram-chat-id", "", "Telegram chat ID for notifications")
+	telegramBotToken := fs.String("telegram-bot-token", "", "Telegram bot token (defaults to $TELEGRAM_BOT_TOKEN)")
+	runner := fs.String("runner", "", "Runners of every job: a label, labels separated by commas (self-hosted,linux) or group:name (defaults to the template's)")
+	preview := fs.Bool("preview", false, "Deploy every pull request to a preview URL and post it as a pull request comment")
+	verify := fs.Bool("verify", false, "Run the lint, typecheck and test scripts in a job the deployment waits for")
+	dryRun := fs.Bool("dry-run", false, "Print a diff of the workflow changes without writing files or creating the project")
+	force := fs.Bool("force", false, "Overwrite existing workflow files without asking, they are backed up first")
+	noInput := fs.Bool("no-input", false, "Never start the interactive form, even in a terminal")
+	all := fs.Bool("all", false, "Set up every project saved in "+config.FileName)
+	discover := fs.String("discover", "", "Set up every app in the folders matching a glob, e.g. 'apps/*', each named after its folder")
+	appSettings := appSettingsFlag{}
+	fs.Var(appSettings, "app", "Set a setting of one app of --all or --discover as `app:flag=value`, e.g. docs:platform=cloudflare, can be repeated")
+
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		return usageError("init takes no arguments, got %q", fs.Args())
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	// The form asks for everything else, so it is only used when no other flags are given
+	configured := len(set)
+	if set["project-path"] {
+		configured--
+	}
+	if set["output"] {
+		configured--
+	}
+	if !*noInput && configured == 0 && isInteractive() {
+		return runTUI(*projectPath)
+	}
+
+	root, err := core.ResolveProjectPath(*projectPath)
+	if err != nil {
+		return fail(err)
+	}
+
+	if *all && *discover != "" {
+		return usageError("--all and --discover cannot be used together")
+	}
+	if (*all || *discover != "") && (set["project-name"] || set["build-folder"]) {
+		return usageError("--project-name and --build-folder select a single project, they cannot be used with --all or --discover")
+	}
+	if !*all && *discover == "" && len(appSettings) > 0 {
+		return usageError("--app sets up a single app of --all or --discover, use the flags directly for a single project")
+	}
+
+	// Use the saved project setup as defaults for anything not given on the command line
+	cfg, err := config.Load(root)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Template variables are merged from the saved setup, the values file and --set, in that order
+	values := make(map[string]string)
+	if err := mergeValues(values, *valuesFile, setValues); err != nil {
+		return fail(err)
+	}
+
+	// newTarget takes the settings from the flags, falling back to the saved
+	// project for the flags that were not given
+	newTarget := func(saved *config.Project) initTarget {
+		t := initTarget{
+			config: models.ProjectConfig{
+				Name:         *projectName,
+				DeployBranch: *deployBranch,
+				BuildFolder:  *buildFolder,
+				Platform:     *platform,
+				Template:     *templateName,
+				Vars:         make(map[string]string),
+				Preview:      *preview,
+				Verify:       *verify,
+				Runner:       *runner,
+			},
+			framework: *framework,
+			teamId:    *vercelTeamID,
+			accountId: *cloudflareAccountID,
+			chatId:    *telegramChatID,
+		}
+
+		if saved != nil {
+			applyDefault(set, "project-name", &t.config.Name, saved.Name)
+			applyDefault(set, "platform", &t.config.Platform, saved.Platform)
+			applyDefault(set, "deploy-branch", &t.config.DeployBranch, saved.DeployBranch)
+			applyDefault(set, "build-folder", &t.config.BuildFolder, saved.BuildFolder)
+			applyDefault(set, "template", &t.config.Template, saved.Template)
+			applyDefault(set, "framework", &t.framework, saved.Framework)
+			applyDefault(set, "vercel-team-id", &t.teamId, saved.TeamId)
+			applyDefault(set, "cloudflare-account-id", &t.accountId, saved.AccountId)
+			applyDefault(set, "telegram-chat-id", &t.chatId, saved.PlatformData().ChatId)
+			applyDefault(set, "runner", &t.config.Runner, saved.Runner)
+			if !set["preview"] {
+				t.config.Preview = saved.Preview
+			}
+			if !set["verify"] {
+				t.config.Verify = saved.Verify
+			}
+			maps.Copy(t.config.Vars, saved.Vars)
+		}
+
+		maps.Copy(t.config.Vars, values)
+		return t
+	}
+
+	branch := ""
+	if set["deploy-branch"] {
+		branch = *deployBranch
+	}
+
+	var targets []initTarget
+	switch {
+	case *all:
+		if len(cfg.Projects) == 0 {
+			return fail(fmt.Errorf("no projects are saved in %s, set them up with `slark init` first", config.FileName))
+		}
+		for i := range cfg.Projects {
+			targets = append(targets, newTarget(&cfg.Projects[i]))
+		}
+
+	case *discover != "":
+		dirs, err := core.DiscoverApps(root, *discover)
+		if err != nil {
+			return fail(err)
+		}
+
+		// Every app is a project named after its folder
+		for _, dir := range dirs {
+			name := path.Base(dir)
+
+			var saved *config.Project
+			if project, ok := cfg.Lookup(name, branch); ok {
+				saved = &project
+			}
+
+			t := newTarget(saved)
+			t.config.Name, t.config.BuildFolder = name, dir
+			targets = append(targets, t)
+		}
+
+	default:
+		var saved *config.Project
+		if project, ok := cfg.Lookup(*projectName, branch); ok {
+			saved = &project
+		}
+
+		t := newTarget(saved)
+
+		// Default the project name to the project directory name
+		if t.config.Name == "" {
+			t.config.Name = filepath.Base(root)
+		}
+		targets = append(targets, t)
+	}
+
+	// Settings given for a single app take precedence over the flags
+	for app, settings := range appSettings {
+		index := slices.IndexFunc(targets, func(t initTarget) bool { return t.config.Name == app })
+		if index < 0 {
+			return usageError("--app names %s, which is not one of the apps being set up", app)
+		}
+		for _, setting := range settings {
+			targets[index].apply(setting.flag, setting.value)
+		}
+	}
+
+	// Fall back to environment variables so secrets don't end up in shell history
+	if *vercelToken == "" {
+		*vercelToken = os.Getenv("VERCEL_TOKEN")
+	}
+	if *cloudflareToken == "" {
+		*cloudflareToken = os.Getenv("CLOUDFLARE_API_TOKEN")
+	}
+	if *telegramBotToken == "" {
+		*telegramBotToken = os.Getenv("TELEGRAM_BOT_TOKEN")
+	}
+
+	// Notifications are sent to the chat ID of each project, the bot token is
+	// only read by the workflow from its GitHub secret
+	if set["telegram-bot-token"] && !slices.ContainsFunc(targets, func(t initTarget) bool { return t.chatId != "" }) {
+		return usageError("--telegram-bot-token needs a chat ID to notify, set --telegram-chat-id (the chat ID may come from %s)", config.FileName)
+	}
+
+	// run sets up a single project, asking in a terminal before replacing
+	// existing files instead of requiring --force
+	run := func(t initTarget) (models.Result, error) {
+		// Use the token of the platform the project is deployed to
+		apiKey := *vercelToken
+		if t.config.Platform == "cloudflare" {
+			apiKey = *cloudflareToken
+		}
+
+		platformData := models.PlatformData{
+			ApiKey:    apiKey,
+			TeamId:    t.teamId,
+			AccountId: t.accountId,
+			BotToken:  *telegramBotToken,
+			ChatId:    t.chatId,
+			Framework: t.framework,
+		}
+
+		opts := models.GenerateOptions{
+			ProjectPath: root,
+			DryRun:      *dryRun,
+			Force:       *force,
+		}
+
+		result, err := core.RunProject(t.config, platformData, opts)
+
+		var overwrite *core.OverwriteError
+		if errors.As(err, &overwrite) && !*noInput && outputFormat == outputText && isInteractive() {
+			if !confirmOverwrite(overwrite.Files) {
+				return models.Result{}, fmt.Errorf("overwriting was declined, no files were changed")
+			}
+
+			opts.Force = true
+			result, err = core.RunProject(t.config, platformData, opts)
+		}
+
+		return result, err
+	}
+
+	if !*all && *discover == "" {
+		result, err := run(targets[0])
+		if err != nil {
+			return fail(err)
+		}
+
+		return printOutput(result, core.FormatResult(result)+"\n")
+	}
+
+	// Set up every project even when one fails, and report the failures at the end
+	type failure struct {
+		Project string `json:"project"`
+		Error   string `json:"error"`
+	}
+	output := struct {
+		Projects []models.Result `json:"projects"`
+		Failed   []failure       `json:"failed"`
+	}{Projects: []models.Result{}, Failed: []failure{}}
+
+	var text strings.Builder
+	for _, t := range targets {
+		fmt.Fprintf(&text, "== %s (%s) ==\n", t.config.Name, t.config.BuildFolder)
+
+		result, err := run(t)
+		if err != nil {
+			output.Failed = append(output.Failed, failure{Project: t.config.Name, Error: err.Error()})
+			fmt.Fprintf(&text, "error: %s\n\n", err)
+			continue
+		}
+
+		output.Projects = append(output.Projects, result)
+		fmt.Fprintf(&text, "%s\n\n", core.FormatResult(result))
+	}
+
+	if len(output.Failed) > 0 {
+		fmt.Fprintf(&text, "%d of %d projects failed\n", len(output.Failed), len(targets))
+	}
+
+	if code := printOutput(output, text.String()); code != exitOK {
+		return code
+	}
+	if len(output.Failed) > 0 {
+		return exitError
+	}
+
+	return exitOK
+}
+
+// initTarget is a project set up by init, with the settings from the flags
+// completed by the saved setup
+type initTarget struct {
+	config    models.ProjectConfig
+	framework string
+	teamId    string
+	accountId string
+	chatId    string
+}
+
+// apply sets a setting of the target given with --app. The flag is one of
+// appSettingFlags.
+func (t *initTarget) apply(flag, value string) {
+	switch flag {
+	case "platform":
+		t.config.Platform = value
+	case "deploy-branch":
+		t.config.DeployBranch = value
+	case "template":
+		t.config.Template = value
+	case "runner":
+		t.config.Runner = value
+	case "preview":
+		t.config.Preview = value == "true"
+	case "verify":
+		t.config.Verify = value == "true"
+	case "framework":
+		t.framework = value
+	case "vercel-team-id":
+		t.teamId = value
+	case "cloudflare-account-id":
+		t.accountId = value
+	case "telegram-chat-id":
+		t.chatId = value
+	}
+}
+
+// appSettingFlags are the init flags that can be set for a single app
+var appSettingFlags = []string{
+	"platform", "deploy-branch", "template", "runner", "preview", "verify",
+	"framework", "vercel-team-id", "cloudflare-account-id", "telegram-chat-id",
+}
+
+// appSetting is a flag value given for a single app with --app
+type appSetting struct {
+	flag  string
+	value string
+}
+
+// appSettingsFlag collects repeated --app app:flag=value flags by app, in
+// the order they were given
+type appSettingsFlag map[string][]appSetting
+
+func (f appSettingsFlag) String() string {
+	return ""
+}
+
+func (f appSettingsFlag) Set(value string) error {
+	app, setting, _ := strings.Cut(value, ":")
+	flag, val, ok := strings.Cut(setting, "=")
+	if app == "" || !ok {
+		return fmt.Errorf("expected app:flag=value, got %q", value)
+	}
+
+	if !slices.Contains(appSettingFlags, flag) {
+		return fmt.Errorf("%s cannot be set for a single app, expected one of %s", flag, strings.Join(appSettingFlags, ", "))
+	}
+	if (flag == "preview" || flag == "verify") && val != "true" && val != "false" {
+		return fmt.Errorf("%s takes true or false, got %q", flag, val)
+	}
+
+	f[app] = append(f[app], appSetting{flag: flag, value: val})
+	return nil
+}
+
+// applyDefault sets value to def unless the flag was given explicitly or def is empty
+func applyDefault(set map[string]bool, name string, value *string, def string) {
+	if !set[name] && def != "" {
+		*value = def
+	}
+}
+
+// confirmOverwrite lists the existing files generating would replace and asks
+// whether to overwrite them
+func confirmOverwrite(files []models.ExistingFile) bool {
+	fmt.Fprintln(os.Stderr, "These workflow files already exist and would be overwritten:")
+	for _, file := range files {
+		fmt.Fprintf(os.Stderr, "  %s (%s)\n", file.Path, core.DescribeExistingFile(file))
+	}
+	fmt.Fprint(os.Stderr, "Overwrite them? The current versions are backed up to .slark/backups. [y/N] ")
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
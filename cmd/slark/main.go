@@ -6,17 +6,22 @@ import (
 	"log/slog"
 	"os"
 
-	"slark/internal/core"
 	"slark/internal/version"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
-	// Define command line flags
+	os.Exit(run())
+}
+
+// run parses the global flags, dispatches to the requested command and
+// returns the process exit code
+func run() int {
+	// Define global command line flags
 	versionFlag := flag.Bool("version", false, "Print the version")
-	listTemplatesFlag := flag.Bool("list-templates", false, "List available templates")
 	debugFlag := flag.Bool("debug", false, "Enable debug mode")
+	flag.Usage = printUsage
 
 	// Parse the flags
 	flag.Parse()
@@ -26,7 +31,7 @@ func main() {
 		f, err := tea.LogToFile("debug.log", "debug")
 		if err != nil {
 			fmt.Println("fatal:", err)
-			os.Exit(1)
+			return exitError
 		}
 		defer f.Close()
 
@@ -38,21 +43,36 @@ func main() {
 
 	// Check for version flag
 	if *versionFlag {
-		fmt.Printf("slark version %s\n", version.GetVersion())
-		return
+		return runVersion(nil)
 	}
 
-	// Check for list templates flag
-	if *listTemplatesFlag {
-		core.ListTemplates()
-		return
+	// Without a command start the interactive setup
+	if flag.NArg() == 0 {
+		if !isInteractive() {
+			printUsage()
+			return exitUsage
+		}
+		return runTUI(".")
 	}
 
-	// Run the main program
-	slog.Info("Starting Slark")
-	p := tea.NewProgram(core.InitialModel(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		slog.Error("error running program", "error", err)
-		os.Exit(1)
+	cmd, ok := findCommand(flag.Arg(0))
+	if !ok {
+		return usageError("unknown command %q, run 'slark help' for usage", flag.Arg(0))
 	}
+
+	return cmd.run(flag.Args()[1:])
+}
+
+// runVersion prints the slark version
+func runVersion(args []string) int {
+	fs := newFlagSet("version")
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	output := struct {
+		Version string `json:"version"`
+	}{Version: version.GetVersion()}
+
+	return printOutput(output, fmt.Sprintf("slark version %s\n", version.GetVersion()))
 }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+
+	"slark/internal/config"
+	"slark/internal/core"
+	"slark/internal/models"
+)
+
+// runRemove tears down the pipeline of a project and branch
+func runRemove(args []string) int {
+	fs := newFlagSet("remove")
+	projectPath := fs.String("project-path", ".", "Path to the project to remove the pipeline from")
+	projectName := fs.String("project-name", "", "Name of the project (defaults to the only one in .slark.yaml)")
+	deployBranch := fs.String("deploy-branch", "", "Deploy branch of the pipeline (defaults to the one in .slark.yaml)")
+	deleteProject := fs.Bool("delete-project", false, "Also delete the project on the deployment platform")
+	vercelTeamID := fs.String("vercel-team-id", "", "Vercel team ID the project belongs to")
+	vercelToken := fs.String("vercel-token", "", "Vercel API token (defaults to $VERCEL_TOKEN)")
+	cloudflareAccountID := fs.String("cloudflare-account-id", "", "Cloudflare account ID the project belongs to")
+	cloudflareToken := fs.String("cloudflare-token", "", "Cloudflare API token (defaults to $CLOUDFLARE_API_TOKEN)")
+	dryRun := fs.Bool("dry-run", false, "Report what would be removed without removing anything")
+
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		return usageError("remove takes no arguments, got %q", fs.Args())
+	}
+
+	root, err := core.ResolveProjectPath(*projectPath)
+	if err != nil {
+		return fail(err)
+	}
+
+	cfg, err := config.Load(root)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Fill in the pipeline from the saved setup when it is unambiguous
+	if project, ok := cfg.Lookup(*projectName, *deployBranch); ok {
+		*projectName = project.Name
+		*deployBranch = project.DeployBranch
+		if *vercelTeamID == "" {
+			*vercelTeamID = project.TeamId
+		}
+		if *cloudflareAccountID == "" {
+			*cloudflareAccountID = project.AccountId
+		}
+	}
+
+	if *projectName == "" || *deployBranch == "" {
+		return usageError("--project-name and --deploy-branch are required when %s does not identify a single project", config.FileName)
+	}
+
+	if *vercelToken == "" {
+		*vercelToken = os.Getenv("VERCEL_TOKEN")
+	}
+	if *cloudflareToken == "" {
+		*cloudflareToken = os.Getenv("CLOUDFLARE_API_TOKEN")
+	}
+
+	// The token is picked once the workflow tells the platform of the project
+	apiKeys := map[string]string{
+		"vercel":     *vercelToken,
+		"cloudflare": *cloudflareToken,
+	}
+
+	platformData := models.PlatformData{
+		TeamId:    *vercelTeamID,
+		AccountId: *cloudflareAccountID,
+	}
+
+	opts := models.GenerateOptions{
+		ProjectPath: root,
+		DryRun:      *dryRun,
+	}
+
+	result, err := core.RemovePipeline(*projectName, *deployBranch, platformData, apiKeys, *deleteProject, opts)
+	if err != nil {
+		return fail(err)
+	}
+
+	return printOutput(result, core.FormatRemoveResult(*projectName, result, opts))
+}
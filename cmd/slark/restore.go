@@ -0,0 +1,58 @@
+package main
+
+import (
+	"slark/internal/core"
+	"slark/internal/models"
+)
+
+// runRestore lists the workflow backups, or restores one of them.
+// It returns the process exit code.
+func runRestore(args []string) int {
+	fs := newFlagSet("restore")
+	projectPath := fs.String("project-path", ".", "Path to the project to restore workflows in")
+	dryRun := fs.Bool("dry-run", false, "Report what would be restored without changing any file")
+
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 1 {
+		return usageError("restore takes at most one backup, got %q", fs.Args())
+	}
+
+	root, err := core.ResolveProjectPath(*projectPath)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Without a backup list the available ones
+	if fs.NArg() == 0 {
+		backups, err := core.ListBackups(root)
+		if err != nil {
+			return fail(err)
+		}
+
+		output := struct {
+			Backups []models.Backup `json:"backups"`
+		}{Backups: append([]models.Backup{}, backups...)}
+
+		return printOutput(output, core.FormatBackups(backups))
+	}
+
+	opts := models.GenerateOptions{
+		ProjectPath: root,
+		DryRun:      *dryRun,
+	}
+
+	backup, replacedId, err := core.RestoreBackup(root, fs.Arg(0), opts)
+	if err != nil {
+		return fail(err)
+	}
+
+	output := struct {
+		DryRun   bool          `json:"dryRun"`
+		Restored models.Backup `json:"restored"`
+		Backup   string        `json:"backup,omitempty"` // Backup of the files the restore replaced
+	}{DryRun: opts.DryRun, Restored: backup, Backup: replacedId}
+
+	return printOutput(output, core.FormatRestore(backup, replacedId, opts))
+}
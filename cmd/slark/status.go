@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+
+	"slark/internal/config"
+	"slark/internal/core"
+	"slark/internal/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runStatus lists the pipelines slark has configured in the project
+func runStatus(args []string) int {
+	fs := newFlagSet("status")
+	projectPath := fs.String("project-path", ".", "Path to the project to inspect")
+	live := fs.Bool("live", false, "Look up the live state of each project on its platform")
+	vercelTeamID := fs.String("vercel-team-id", "", "Vercel team ID the projects belong to (defaults to the one in .slark.yaml)")
+	vercelToken := fs.String("vercel-token", "", "Vercel API token used with --live (defaults to $VERCEL_TOKEN)")
+	tui := fs.Bool("tui", false, "Browse the pipelines in an interactive screen")
+
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		return usageError("status takes no arguments, got %q", fs.Args())
+	}
+
+	root, err := core.ResolveProjectPath(*projectPath)
+	if err != nil {
+		return fail(err)
+	}
+
+	pipelines, err := core.ListPipelines(root)
+	if err != nil {
+		return fail(err)
+	}
+
+	if *live {
+		if *vercelToken == "" {
+			*vercelToken = os.Getenv("VERCEL_TOKEN")
+		}
+		if *vercelToken == "" {
+			return usageError("--live needs a Vercel API token, pass --vercel-token or set $VERCEL_TOKEN")
+		}
+
+		if *vercelTeamID == "" {
+			cfg, err := config.Load(root)
+			if err != nil {
+				return fail(err)
+			}
+			if project, ok := cfg.Lookup("", ""); ok {
+				*vercelTeamID = project.TeamId
+			}
+		}
+
+		core.AddLiveState(pipelines, models.PlatformData{
+			ApiKey: *vercelToken,
+			TeamId: *vercelTeamID,
+		})
+	}
+
+	if *tui {
+		if _, err := tea.NewProgram(core.NewStatusModel(pipelines)).Run(); err != nil {
+			return fail(err)
+		}
+		return exitOK
+	}
+
+	output := struct {
+		Pipelines []models.Pipeline `json:"pipelines"`
+	}{Pipelines: append([]models.Pipeline{}, pipelines...)}
+
+	return printOutput(output, core.FormatPipelines(pipelines))
+}
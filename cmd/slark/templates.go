@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"slark/internal/core"
+	"slark/internal/models"
+)
+
+// runTemplates lists, shows, scaffolds or renders templates
+func runTemplates(args []string) int {
+	fs := newFlagSet("templates")
+	projectPath := fs.String("project-path", ".", "Path to the project whose .slark/templates override the built-in templates")
+	from := fs.String("from", "vercel/basic", "Template a new template starts from (new)")
+	inProject := fs.Bool("project", false, "Create the new template in the project instead of the user config directory (new)")
+	valuesFile := fs.String("values", "", "YAML file of template variables (render)")
+	setValues := keyValueFlag{}
+	fs.Var(setValues, "set", "Set a template variable as `key=value`, can be repeated (render)")
+
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	root, err := core.ResolveProjectPath(*projectPath)
+	if err != nil {
+		return fail(err)
+	}
+
+	switch fs.Arg(0) {
+	case "list", "":
+		templates, err := core.ListTemplates(root)
+		if err != nil {
+			return fail(err)
+		}
+
+		output := struct {
+			Templates []models.TemplateInfo `json:"templates"`
+		}{Templates: append([]models.TemplateInfo{}, templates...)}
+
+		return printOutput(output, core.FormatTemplates(templates))
+
+	case "show":
+		if fs.NArg() != 2 {
+			return usageError("templates show expects a template name, e.g. vercel/basic")
+		}
+
+		info, content, err := core.ShowTemplate(root, fs.Arg(1))
+		if err != nil {
+			return fail(err)
+		}
+
+		output := struct {
+			models.TemplateInfo
+			Content string `json:"content"`
+		}{TemplateInfo: info, Content: content}
+
+		// Report the layer on stderr so the template itself can be piped
+		if outputFormat == outputText {
+			fmt.Fprintf(os.Stderr, "%s\n", core.FormatTemplateSource(info))
+		}
+
+		return printOutput(output, content)
+
+	case "new":
+		if fs.NArg() != 2 {
+			return usageError("templates new expects a template name, e.g. vercel/custom")
+		}
+
+		templatePath, valuesPath, err := core.NewTemplate(root, fs.Arg(1), *from, *inProject)
+		if err != nil {
+			return fail(err)
+		}
+
+		output := struct {
+			Template string `json:"template"`
+			Values   string `json:"values"`
+		}{Template: templatePath, Values: valuesPath}
+
+		text := fmt.Sprintf("Created %s\nCreated %s\n\nPreview it with: slark templates render %s --values %s\n", templatePath, valuesPath, fs.Arg(1), valuesPath)
+		return printOutput(output, text)
+
+	case "render":
+		if fs.NArg() != 2 {
+			return usageError("templates render expects a template name, e.g. vercel/basic")
+		}
+
+		values := make(map[string]string)
+		if err := mergeValues(values, *valuesFile, setValues); err != nil {
+			return fail(err)
+		}
+
+		content, err := core.RenderTemplate(root, fs.Arg(1), values)
+		if err != nil {
+			return fail(err)
+		}
+
+		output := struct {
+			Name    string `json:"name"`
+			Content string `json:"content"`
+		}{Name: fs.Arg(1), Content: content}
+
+		return printOutput(output, content)
+
+	default:
+		return usageError("unknown templates subcommand %q, expected list, show, new or render", fs.Arg(0))
+	}
+}
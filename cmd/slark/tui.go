@@ -0,0 +1,33 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"slark/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+)
+
+// isInteractive reports whether slark is attached to a terminal it can prompt on
+func isInteractive() bool {
+	return term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd())
+}
+
+// runTUI runs the interactive setup form for the project at projectPath
+func runTUI(projectPath string) int {
+	root, err := core.ResolveProjectPath(projectPath)
+	if err != nil {
+		return fail(err)
+	}
+
+	slog.Info("Starting Slark", "projectPath", root)
+	p := tea.NewProgram(core.InitialModel(root), tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		slog.Error("error running program", "error", err)
+		return exitError
+	}
+
+	return exitOK
+}
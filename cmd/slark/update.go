@@ -0,0 +1,59 @@
+package main
+
+import (
+	"slark/internal/core"
+	"slark/internal/models"
+)
+
+// runUpdate regenerates existing slark workflows and merges in local edits.
+// It returns the process exit code.
+func runUpdate(args []string) int {
+	fs := newFlagSet("update")
+	projectPath := fs.String("project-path", ".", "Path to the project to update")
+	dryRun := fs.Bool("dry-run", false, "Print the merged changes without writing files")
+
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		return usageError("update takes no arguments, got %q", fs.Args())
+	}
+
+	root, err := core.ResolveProjectPath(*projectPath)
+	if err != nil {
+		return fail(err)
+	}
+
+	opts := models.GenerateOptions{
+		ProjectPath: root,
+		DryRun:      *dryRun,
+	}
+
+	results, err := core.UpdateWorkflows(opts)
+	if err != nil {
+		return fail(err)
+	}
+
+	summary, err := core.FormatUpdateResults(results, opts)
+	if err != nil {
+		return fail(err)
+	}
+
+	output := struct {
+		DryRun bool                 `json:"dryRun"`
+		Files  []models.UpdatedFile `json:"files"`
+	}{DryRun: opts.DryRun, Files: append([]models.UpdatedFile{}, results...)}
+
+	if code := printOutput(output, summary); code != exitOK {
+		return code
+	}
+
+	// Conflicts need manual attention, so report them as a failure
+	for _, result := range results {
+		if result.Status == "conflict" {
+			return exitError
+		}
+	}
+
+	return exitOK
+}
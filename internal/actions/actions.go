@@ -0,0 +1,457 @@
+// Package actions is a typed model of GitHub Actions workflow files. Generated
+// workflows are decoded into it and written back through the YAML encoder, so
+// values are always quoted where YAML needs it.
+package actions
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Workflow is a GitHub Actions workflow file
+type Workflow struct {
+	Name  string         `yaml:"name,omitempty"`
+	On    On             `yaml:"on"`
+	Env   Map            `yaml:"env,omitempty"`
+	Jobs  Jobs           `yaml:"jobs"`
+	Extra map[string]any `yaml:",inline"` // Keys slark does not model, kept as they are
+}
+
+// On lists the events that trigger a workflow
+type On struct {
+	Push         *Event         `yaml:"push,omitempty"`
+	PullRequest  *Event         `yaml:"pull_request,omitempty"`
+	WorkflowCall *WorkflowCall  `yaml:"workflow_call,omitempty"`
+	Extra        map[string]any `yaml:",inline"`
+}
+
+// Event filters when a push or pull request triggers a workflow
+type Event struct {
+	Types          []string `yaml:"types,omitempty"`
+	Branches       []string `yaml:"branches,omitempty"`
+	BranchesIgnore []string `yaml:"branches-ignore,omitempty"`
+	Tags           []string `yaml:"tags,omitempty"`
+	Paths          []string `yaml:"paths,omitempty"`
+	PathsIgnore    []string `yaml:"paths-ignore,omitempty"`
+}
+
+// WorkflowCall makes a workflow reusable from other workflows
+type WorkflowCall struct {
+	Inputs Inputs         `yaml:"inputs,omitempty"`
+	Extra  map[string]any `yaml:",inline"`
+}
+
+// Input is an input of a reusable workflow
+type Input struct {
+	Name        string `yaml:"-"`
+	Description string `yaml:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty"`
+	Type        string `yaml:"type,omitempty"`
+	Default     any    `yaml:"default,omitempty"`
+}
+
+// Inputs are the inputs of a reusable workflow in the order they are written
+type Inputs []Input
+
+// Job is a job of a workflow
+type Job struct {
+	Id          string         `yaml:"-"` // Key of the job in the jobs mapping
+	Name        string         `yaml:"name,omitempty"`
+	Needs       StringList     `yaml:"needs,omitempty"`
+	If          string         `yaml:"if,omitempty"`
+	RunsOn      RunsOn         `yaml:"runs-on,omitempty"`
+	Permissions any            `yaml:"permissions,omitempty"` // A mapping of scopes or read-all/write-all
+	Uses        string         `yaml:"uses,omitempty"`        // Reusable workflow the job calls
+	With        Map            `yaml:"with,omitempty"`
+	Env         Map            `yaml:"env,omitempty"`
+	Steps       []Step         `yaml:"steps,omitempty"`
+	Outputs     Map            `yaml:"outputs,omitempty"`
+	Extra       map[string]any `yaml:",inline"`
+}
+
+// Jobs are the jobs of a workflow in the order they are written
+type Jobs []*Job
+
+// Step is a step of a job
+type Step struct {
+	Name             string         `yaml:"name,omitempty"`
+	Id               string         `yaml:"id,omitempty"`
+	If               string         `yaml:"if,omitempty"`
+	Uses             string         `yaml:"uses,omitempty"`
+	With             Map            `yaml:"with,omitempty"`
+	WorkingDirectory string         `yaml:"working-directory,omitempty"`
+	Shell            string         `yaml:"shell,omitempty"`
+	Run              string         `yaml:"run,omitempty"`
+	Env              Map            `yaml:"env,omitempty"`
+	Extra            map[string]any `yaml:",inline"`
+}
+
+// RunsOn selects the runners of a job: runners having all the labels, or
+// runners of a group, optionally narrowed down by labels
+type RunsOn struct {
+	Group  string
+	Labels []string
+}
+
+// StringList is a list of strings written as a single string when it has one
+// element
+type StringList []string
+
+// Map is a mapping of strings in the order it is written, used for with,
+// env and outputs
+type Map []MapItem
+
+// MapItem is an entry of a Map
+type MapItem struct {
+	Key   string
+	Value string
+	// Literal values like 18 or false are written without quotes, they were
+	// not strings in the source
+	Literal bool
+}
+
+// Job returns the job with the given id, or nil
+func (w *Workflow) Job(id string) *Job {
+	for _, job := range w.Jobs {
+		if job.Id == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// SetRunsOn runs every job that runs steps on the given runners. Jobs calling
+// a reusable workflow pick their runners in the called workflow.
+func (w *Workflow) SetRunsOn(runsOn RunsOn) {
+	for _, job := range w.Jobs {
+		if job.Uses == "" {
+			job.RunsOn = runsOn
+		}
+	}
+}
+
+// Marshal encodes the workflow as YAML, with a blank line between jobs
+func (w *Workflow) Marshal() (string, error) {
+	var doc yaml.Node
+	if err := doc.Encode(w); err != nil {
+		return "", fmt.Errorf("failed to encode workflow: %w", err)
+	}
+
+	// "on" is a boolean in YAML 1.1 so the encoder quotes it, GitHub reads it as a key
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value == "on" {
+			doc.Content[i].Style = 0
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return "", fmt.Errorf("failed to encode workflow: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode workflow: %w", err)
+	}
+
+	return spaceJobs(buf.String()), nil
+}
+
+// spaceJobs adds a blank line between the jobs of an encoded workflow. Job
+// keys are the only lines indented by two spaces under jobs, anything deeper
+// including block scalars is indented further.
+func spaceJobs(text string) string {
+	var b strings.Builder
+	inJobs, first := false, true
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		switch {
+		case strings.TrimSpace(line) == "":
+			// Blank lines of block scalars belong to the job they are in
+		case line[0] != ' ':
+			inJobs = strings.TrimSpace(line) == "jobs:"
+			first = true
+		case inJobs && strings.HasPrefix(line, "  ") && len(line) > 2 && line[2] != ' ':
+			if !first {
+				b.WriteString("\n")
+			}
+			first = false
+		}
+		b.WriteString(line)
+	}
+
+	return b.String()
+}
+
+// UnmarshalYAML reads events written as a name, a list of names or a mapping
+func (o *On) UnmarshalYAML(node *yaml.Node) error {
+	type plain On
+
+	events := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		events.Content = append(events.Content, node, emptyMapping())
+	case yaml.SequenceNode:
+		for _, event := range node.Content {
+			events.Content = append(events.Content, event, emptyMapping())
+		}
+	case yaml.MappingNode:
+		// Events without filters are written with an empty value
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value := node.Content[i+1]
+			if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+				value = emptyMapping()
+			}
+			events.Content = append(events.Content, node.Content[i], value)
+		}
+	default:
+		return fmt.Errorf("line %d: invalid workflow triggers", node.Line)
+	}
+
+	return events.Decode((*plain)(o))
+}
+
+// emptyMapping returns a node for {}
+func emptyMapping() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+// UnmarshalYAML reads the jobs mapping keeping its order
+func (j *Jobs) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: jobs must be a mapping", node.Line)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		job := &Job{Id: node.Content[i].Value}
+		if err := node.Content[i+1].Decode(job); err != nil {
+			return fmt.Errorf("job %s: %w", job.Id, err)
+		}
+		*j = append(*j, job)
+	}
+
+	return nil
+}
+
+// MarshalYAML writes the jobs as a mapping in their order
+func (j Jobs) MarshalYAML() (any, error) {
+	node := emptyMapping()
+	for _, job := range j {
+		value := &yaml.Node{}
+		if err := value.Encode(job); err != nil {
+			return nil, fmt.Errorf("job %s: %w", job.Id, err)
+		}
+		node.Content = append(node.Content, stringNode(job.Id), value)
+	}
+	return node, nil
+}
+
+// UnmarshalYAML reads the inputs mapping keeping its order
+func (in *Inputs) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: inputs must be a mapping", node.Line)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		input := Input{Name: node.Content[i].Value}
+		if err := node.Content[i+1].Decode(&input); err != nil {
+			return fmt.Errorf("input %s: %w", input.Name, err)
+		}
+		*in = append(*in, input)
+	}
+
+	return nil
+}
+
+// MarshalYAML writes the inputs as a mapping in their order
+func (in Inputs) MarshalYAML() (any, error) {
+	node := emptyMapping()
+	for _, input := range in {
+		value := &yaml.Node{}
+		if err := value.Encode(input); err != nil {
+			return nil, fmt.Errorf("input %s: %w", input.Name, err)
+		}
+		node.Content = append(node.Content, stringNode(input.Name), value)
+	}
+	return node, nil
+}
+
+// IsZero reports whether no runner is selected, leaving out runs-on
+func (r RunsOn) IsZero() bool {
+	return r.Group == "" && len(r.Labels) == 0
+}
+
+// ParseRunsOn reads runners written as labels separated by commas, such as
+// ubuntu-latest or self-hosted,linux,x64. A group:name entry selects a runner
+// group, narrowed down by the other labels.
+func ParseRunsOn(spec string) (RunsOn, error) {
+	var r RunsOn
+	for _, label := range strings.Split(spec, ",") {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			continue
+		}
+
+		group, ok := strings.CutPrefix(label, "group:")
+		if !ok {
+			r.Labels = append(r.Labels, label)
+			continue
+		}
+
+		group = strings.TrimSpace(group)
+		if group == "" {
+			return RunsOn{}, fmt.Errorf("invalid runner %q, group: needs a group name", spec)
+		}
+		if r.Group != "" {
+			return RunsOn{}, fmt.Errorf("invalid runner %q, only one group can be selected", spec)
+		}
+		r.Group = group
+	}
+
+	if r.IsZero() {
+		return RunsOn{}, fmt.Errorf("invalid runner %q, expected a label, labels separated by commas or group:name", spec)
+	}
+
+	return r, nil
+}
+
+// String writes the runners in the form ParseRunsOn reads
+func (r RunsOn) String() string {
+	labels := r.Labels
+	if r.Group != "" {
+		labels = append([]string{"group:" + r.Group}, labels...)
+	}
+	return strings.Join(labels, ",")
+}
+
+// UnmarshalYAML reads a label, a list of labels or a group with labels
+func (r *RunsOn) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		var labels StringList
+		if err := node.Decode(&labels); err != nil {
+			return err
+		}
+		*r = RunsOn{Labels: labels}
+		return nil
+	}
+
+	var group struct {
+		Group  string     `yaml:"group"`
+		Labels StringList `yaml:"labels"`
+	}
+	if err := node.Decode(&group); err != nil {
+		return err
+	}
+	*r = RunsOn{Group: group.Group, Labels: group.Labels}
+	return nil
+}
+
+// MarshalYAML writes a group as a mapping and labels as a label or a list
+func (r RunsOn) MarshalYAML() (any, error) {
+	if r.Group == "" {
+		return StringList(r.Labels), nil
+	}
+
+	node := emptyMapping()
+	node.Content = append(node.Content, stringNode("group"), stringNode(r.Group))
+	if len(r.Labels) > 0 {
+		labels := &yaml.Node{}
+		if err := labels.Encode(StringList(r.Labels)); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, stringNode("labels"), labels)
+	}
+	return node, nil
+}
+
+// UnmarshalYAML reads a single string or a list of strings
+func (s *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = StringList{node.Value}
+		return nil
+	}
+
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// MarshalYAML writes a single string when the list has one element
+func (s StringList) MarshalYAML() (any, error) {
+	if len(s) == 1 {
+		return stringNode(s[0]), nil
+	}
+
+	node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, value := range s {
+		node.Content = append(node.Content, stringNode(value))
+	}
+	return node, nil
+}
+
+// Get returns the value of key and whether the map has it
+func (m Map) Get(key string) (string, bool) {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set replaces the value of key, or adds it at the end
+func (m *Map) Set(key, value string) {
+	for i, item := range *m {
+		if item.Key == key {
+			(*m)[i] = MapItem{Key: key, Value: value}
+			return
+		}
+	}
+	*m = append(*m, MapItem{Key: key, Value: value})
+}
+
+// UnmarshalYAML reads a mapping of scalars keeping its order
+func (m *Map) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping", node.Line)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			return fmt.Errorf("line %d: %s must be a single value", value.Line, key.Value)
+		}
+
+		*m = append(*m, MapItem{
+			Key:     key.Value,
+			Value:   value.Value,
+			Literal: value.Tag != "" && value.Tag != "!!str",
+		})
+	}
+
+	return nil
+}
+
+// MarshalYAML writes the map in its order, quoting strings where needed
+func (m Map) MarshalYAML() (any, error) {
+	node := emptyMapping()
+	for _, item := range m {
+		value := stringNode(item.Value)
+		if item.Literal {
+			value.Tag = ""
+		}
+		node.Content = append(node.Content, stringNode(item.Key), value)
+	}
+	return node, nil
+}
+
+// stringNode returns a node for a string, quoted by the encoder when needed
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
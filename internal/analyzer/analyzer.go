@@ -0,0 +1,355 @@
+// Package analyzer inspects a JavaScript project to find the package manager
+// and the Node.js version it is built with, from its lockfile, version files
+// and package.json
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Package managers slark generates install steps for
+const (
+	Npm  = "npm"
+	Pnpm = "pnpm"
+	Yarn = "yarn"
+	Bun  = "bun"
+)
+
+// frameworks maps the package marking a framework to the folder its static
+// build is written to, in the order they are looked for. Frameworks built on
+// Vite come before Vite itself.
+var frameworks = []struct {
+	dependency string
+	name       string
+	outputDir  string
+}{
+	{"next", "nextjs", "out"},
+	{"gatsby", "gatsby", "public"},
+	{"@remix-run/dev", "remix", "build/client"},
+	{"@react-router/dev", "react-router", "build/client"},
+	{"astro", "astro", "dist"},
+	{"@sveltejs/kit", "sveltekit-1", ".svelte-kit/cloudflare"},
+	{"nuxt", "nuxtjs", "dist"},
+	{"@docusaurus/core", "docusaurus-2", "build"},
+	{"hexo", "hexo", "public"},
+	{"@11ty/eleventy", "eleventy", "_site"},
+	{"react-scripts", "create-react-app", "build"},
+	{"@vue/cli-service", "vue", "dist"},
+	{"vite", "vite", "dist"},
+}
+
+// defaultOutputDir is the build folder of projects without a known framework
+const defaultOutputDir = "dist"
+
+// lockfiles maps the lockfiles to their package manager, in the order they
+// are looked for when a folder has several
+var lockfiles = []struct {
+	name           string
+	packageManager string
+}{
+	{"pnpm-lock.yaml", Pnpm},
+	{"yarn.lock", Yarn},
+	{"bun.lockb", Bun},
+	{"bun.lock", Bun},
+	{"package-lock.json", Npm},
+}
+
+// Project is what the analysis found out about a project
+type Project struct {
+	PackageManager        string            // npm, pnpm, yarn or bun
+	PackageManagerVersion string            // Version pinned by the packageManager field of package.json, if any
+	Lockfile              string            // Lockfile relative to the root with forward slashes, empty when there is none
+	YarnBerry             bool              // Whether yarn is version 2 or later
+	NodeVersion           string            // Node.js version, empty when the project does not pin one
+	NodeVersionSource     string            // File the Node.js version was read from
+	Scripts               map[string]string // Scripts of the package.json of the project
+	Framework             string            // Framework found in the dependencies, named like the Vercel presets, empty when unknown
+
+	packageManagerDir string // Folder of the package.json pinning the package manager
+}
+
+// manifest is the part of package.json the analysis reads
+type manifest struct {
+	PackageManager  string            `json:"packageManager"`
+	Scripts         map[string]string `json:"scripts"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+	Engines         struct {
+		Node string `json:"node"`
+	} `json:"engines"`
+}
+
+// Analyze inspects the project in dir, relative to the repository root.
+// Lockfiles and version files are looked for in dir and then in its parents
+// up to the root, where monorepos keep them.
+func Analyze(root, dir string) (Project, error) {
+	var project Project
+
+	dirs := searchDirs(dir)
+
+	// The nearest lockfile decides the package manager
+	for _, d := range dirs {
+		for _, lockfile := range lockfiles {
+			if exists(filepath.Join(root, filepath.FromSlash(d), lockfile.name)) {
+				project.PackageManager = lockfile.packageManager
+				project.Lockfile = path.Join(d, lockfile.name)
+				break
+			}
+		}
+		if project.Lockfile != "" {
+			break
+		}
+	}
+
+	// The packageManager field names the package manager when there is no lockfile yet
+	for i, d := range dirs {
+		m, err := readManifest(filepath.Join(root, filepath.FromSlash(d), "package.json"))
+		if err != nil {
+			return project, err
+		}
+
+		if i == 0 {
+			project.Scripts = m.Scripts
+			project.Framework = detectFramework(m)
+		}
+
+		if project.PackageManagerVersion == "" && m.PackageManager != "" {
+			name, version, _ := strings.Cut(m.PackageManager, "@")
+			if project.PackageManager == "" {
+				project.PackageManager = name
+			}
+			if name == project.PackageManager {
+				project.PackageManagerVersion, _, _ = strings.Cut(version, "+")
+				project.packageManagerDir = d
+			}
+		}
+
+		if project.NodeVersion == "" && m.Engines.Node != "" {
+			project.NodeVersion = m.Engines.Node
+			project.NodeVersionSource = path.Join(d, "package.json")
+		}
+	}
+
+	if project.PackageManager == "" {
+		project.PackageManager = Npm
+	}
+
+	if project.PackageManager == Yarn {
+		project.YarnBerry = majorVersion(project.PackageManagerVersion) >= 2
+		for _, d := range dirs {
+			if exists(filepath.Join(root, filepath.FromSlash(d), ".yarnrc.yml")) {
+				project.YarnBerry = true
+			}
+		}
+	}
+
+	// Version files take precedence over the engines field
+	for _, d := range dirs {
+		version, source, err := readVersionFile(root, d)
+		if err != nil {
+			return project, err
+		}
+		if version != "" {
+			project.NodeVersion, project.NodeVersionSource = version, source
+			break
+		}
+	}
+
+	return project, nil
+}
+
+// InstallCommand returns the command installing the dependencies exactly as
+// the lockfile pins them
+func (p Project) InstallCommand() string {
+	switch p.PackageManager {
+	case Pnpm:
+		return "pnpm install --frozen-lockfile"
+	case Yarn:
+		if p.YarnBerry {
+			return "yarn install --immutable"
+		}
+		return "yarn install --frozen-lockfile"
+	case Bun:
+		return "bun install --frozen-lockfile"
+	}
+
+	if p.Lockfile == "" {
+		return "npm install"
+	}
+	return "npm ci"
+}
+
+// RunCommand returns the command running a script of package.json, to be
+// followed by the script name. A missing script fails the step.
+func (p Project) RunCommand() string {
+	switch p.PackageManager {
+	case Pnpm:
+		return "pnpm run"
+	case Yarn:
+		return "yarn run"
+	case Bun:
+		return "bun run"
+	}
+	return "npm run"
+}
+
+// OutputDir returns the folder the build script of the project writes the
+// static site to, relative to the project
+func (p Project) OutputDir() string {
+	for _, framework := range frameworks {
+		if framework.name == p.Framework {
+			return framework.outputDir
+		}
+	}
+	return defaultOutputDir
+}
+
+// detectFramework returns the first framework package.json depends on
+func detectFramework(m manifest) string {
+	for _, framework := range frameworks {
+		_, dependency := m.Dependencies[framework.dependency]
+		_, devDependency := m.DevDependencies[framework.dependency]
+		if dependency || devDependency {
+			return framework.name
+		}
+	}
+	return ""
+}
+
+// Script returns the first of the named scripts the project has, or empty.
+// The test script npm init writes, which always fails, does not count.
+func (p Project) Script(names ...string) string {
+	for _, name := range names {
+		script, ok := p.Scripts[name]
+		if !ok || strings.Contains(script, "no test specified") {
+			continue
+		}
+		return name
+	}
+	return ""
+}
+
+// NodeCache returns the package manager whose store actions/setup-node can
+// cache, empty when it cannot cache it
+func (p Project) NodeCache() string {
+	if p.Lockfile == "" || p.PackageManager == Bun {
+		return ""
+	}
+	return p.PackageManager
+}
+
+// PnpmVersion returns the pnpm version to install. pnpm/action-setup reads
+// the packageManager field of the root package.json itself, otherwise the
+// major version is guessed from the lockfile format.
+func (p Project) PnpmVersion(root string) (string, error) {
+	if p.PackageManager != Pnpm || p.packageManagerDir == "." {
+		return "", nil
+	}
+	if p.PackageManagerVersion != "" {
+		return p.PackageManagerVersion, nil
+	}
+	if p.Lockfile == "" {
+		return "latest", nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(p.Lockfile)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", p.Lockfile, err)
+	}
+
+	var lockfile struct {
+		LockfileVersion string `yaml:"lockfileVersion"`
+	}
+	if err := yaml.Unmarshal(data, &lockfile); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", p.Lockfile, err)
+	}
+
+	switch major := majorVersion(lockfile.LockfileVersion); {
+	case major >= 9:
+		return "9", nil
+	case major >= 6:
+		return "8", nil
+	case major == 5:
+		return "7", nil
+	}
+	return "latest", nil
+}
+
+// searchDirs returns dir and its parents up to the root, nearest first
+func searchDirs(dir string) []string {
+	dir = path.Clean(filepath.ToSlash(dir))
+
+	var dirs []string
+	for dir != "." && dir != "/" && !strings.HasPrefix(dir, "..") {
+		dirs = append(dirs, dir)
+		dir = path.Dir(dir)
+	}
+	return append(dirs, ".")
+}
+
+// readVersionFile reads the Node.js version from .nvmrc or .node-version in dir
+func readVersionFile(root, dir string) (string, string, error) {
+	for _, name := range []string{".nvmrc", ".node-version"} {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(dir), name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		// The version is the first line that is not a comment
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			return strings.TrimPrefix(line, "v"), path.Join(dir, name), nil
+		}
+	}
+
+	return "", "", nil
+}
+
+// readManifest reads a package.json file, a missing file is empty
+func readManifest(file string) (manifest, error) {
+	var m manifest
+
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return m, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	return m, nil
+}
+
+// majorVersion returns the major version of a version such as 4.1.0, or 0
+func majorVersion(version string) int {
+	major, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(strings.Trim(major, "'\" "))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// exists reports whether the file exists
+func exists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
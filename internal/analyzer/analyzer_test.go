@@ -0,0 +1,227 @@
+package analyzer
+
+import (
+	"testing"
+
+	"slark/internal/testutil"
+)
+
+func TestAnalyzePackageManager(t *testing.T) {
+	tests := []struct {
+		desc           string
+		dir            string
+		files          map[string]string
+		packageManager string
+		lockfile       string
+		version        string
+	}{
+		{"no lockfile", ".", nil, Npm, "", ""},
+		{"npm", ".", map[string]string{"package-lock.json": "{}"}, Npm, "package-lock.json", ""},
+		{"bun text lockfile", ".", map[string]string{"bun.lock": ""}, Bun, "bun.lock", ""},
+		{"bun binary lockfile", ".", map[string]string{"bun.lockb": ""}, Bun, "bun.lockb", ""},
+		{"pnpm before yarn", ".", map[string]string{"yarn.lock": "", "pnpm-lock.yaml": ""}, Pnpm, "pnpm-lock.yaml", ""},
+		{"yarn before npm", ".", map[string]string{"package-lock.json": "{}", "yarn.lock": ""}, Yarn, "yarn.lock", ""},
+		{"bun before npm", ".", map[string]string{"package-lock.json": "{}", "bun.lockb": ""}, Bun, "bun.lockb", ""},
+		{
+			"lockfile at the root of a monorepo", "apps/web",
+			map[string]string{"pnpm-lock.yaml": "", "apps/web/package.json": "{}"},
+			Pnpm, "pnpm-lock.yaml", "",
+		},
+		{
+			"nearest lockfile wins", "apps/web",
+			map[string]string{"pnpm-lock.yaml": "", "apps/web/package-lock.json": "{}"},
+			Npm, "apps/web/package-lock.json", "",
+		},
+		{
+			"packageManager field without lockfile", ".",
+			map[string]string{"package.json": `{"packageManager": "pnpm@9.1.0+sha512.abc"}`},
+			Pnpm, "", "9.1.0",
+		},
+		{
+			"packageManager field of another manager is ignored", ".",
+			map[string]string{"yarn.lock": "", "package.json": `{"packageManager": "pnpm@9.1.0"}`},
+			Yarn, "yarn.lock", "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			root := t.TempDir()
+			testutil.WriteFiles(t, root, tt.files)
+
+			project, err := Analyze(root, tt.dir)
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if project.PackageManager != tt.packageManager || project.Lockfile != tt.lockfile || project.PackageManagerVersion != tt.version {
+				t.Errorf("Analyze = %s %q %q, want %s %q %q", project.PackageManager, project.Lockfile, project.PackageManagerVersion,
+					tt.packageManager, tt.lockfile, tt.version)
+			}
+		})
+	}
+}
+
+func TestAnalyzeYarnBerry(t *testing.T) {
+	tests := []struct {
+		desc  string
+		dir   string
+		files map[string]string
+		berry bool
+	}{
+		{"classic", ".", map[string]string{"yarn.lock": ""}, false},
+		{"yarnrc", ".", map[string]string{"yarn.lock": "", ".yarnrc.yml": ""}, true},
+		{"yarnrc at the root of a monorepo", "apps/web", map[string]string{"yarn.lock": "", ".yarnrc.yml": ""}, true},
+		{"packageManager 1", ".", map[string]string{"yarn.lock": "", "package.json": `{"packageManager": "yarn@1.22.19"}`}, false},
+		{"packageManager 4", ".", map[string]string{"yarn.lock": "", "package.json": `{"packageManager": "yarn@4.1.0"}`}, true},
+		{"not yarn", ".", map[string]string{"package-lock.json": "{}", ".yarnrc.yml": ""}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			root := t.TempDir()
+			testutil.WriteFiles(t, root, tt.files)
+
+			project, err := Analyze(root, tt.dir)
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if project.YarnBerry != tt.berry {
+				t.Errorf("YarnBerry = %t, want %t", project.YarnBerry, tt.berry)
+			}
+		})
+	}
+}
+
+func TestPnpmVersion(t *testing.T) {
+	tests := []struct {
+		desc    string
+		dir     string
+		files   map[string]string
+		version string
+	}{
+		{"lockfile 9", ".", map[string]string{"pnpm-lock.yaml": "lockfileVersion: '9.0'\n"}, "9"},
+		{"lockfile 6", ".", map[string]string{"pnpm-lock.yaml": "lockfileVersion: '6.0'\n"}, "8"},
+		{"lockfile 5.4", ".", map[string]string{"pnpm-lock.yaml": "lockfileVersion: 5.4\n"}, "7"},
+		{"old lockfile", ".", map[string]string{"pnpm-lock.yaml": "lockfileVersion: 4\n"}, "latest"},
+		{"no lockfile", "apps/web", map[string]string{"apps/web/package.json": `{"packageManager": "pnpm"}`}, "latest"},
+		{
+			"packageManager field of the root", ".",
+			map[string]string{"pnpm-lock.yaml": "lockfileVersion: '9.0'\n", "package.json": `{"packageManager": "pnpm@8.15.0"}`},
+			"",
+		},
+		{
+			"packageManager field of the app", "apps/web",
+			map[string]string{"pnpm-lock.yaml": "lockfileVersion: '9.0'\n", "apps/web/package.json": `{"packageManager": "pnpm@8.15.0"}`},
+			"8.15.0",
+		},
+		{"not pnpm", ".", map[string]string{"package-lock.json": "{}"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			root := t.TempDir()
+			testutil.WriteFiles(t, root, tt.files)
+
+			project, err := Analyze(root, tt.dir)
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			version, err := project.PnpmVersion(root)
+			if err != nil {
+				t.Fatalf("PnpmVersion: %v", err)
+			}
+			if version != tt.version {
+				t.Errorf("PnpmVersion = %q, want %q", version, tt.version)
+			}
+		})
+	}
+}
+
+func TestAnalyzeNodeVersion(t *testing.T) {
+	tests := []struct {
+		desc    string
+		dir     string
+		files   map[string]string
+		version string
+		source  string
+	}{
+		{"none", ".", nil, "", ""},
+		{"nvmrc", ".", map[string]string{".nvmrc": "20.11.0\n"}, "20.11.0", ".nvmrc"},
+		{"v prefix", ".", map[string]string{".nvmrc": "v18\n"}, "18", ".nvmrc"},
+		{"comments and spaces", ".", map[string]string{".nvmrc": "# pinned for CI\n\n  lts/iron  \n"}, "lts/iron", ".nvmrc"},
+		{"node-version", ".", map[string]string{".node-version": "22.2.0"}, "22.2.0", ".node-version"},
+		{"nvmrc before node-version", ".", map[string]string{".nvmrc": "20", ".node-version": "22"}, "20", ".nvmrc"},
+		{"engines", ".", map[string]string{"package.json": `{"engines": {"node": ">=18"}}`}, ">=18", "package.json"},
+		{
+			"version file before engines", ".",
+			map[string]string{".nvmrc": "20", "package.json": `{"engines": {"node": ">=18"}}`},
+			"20", ".nvmrc",
+		},
+		{
+			"nearest version file wins", "apps/web",
+			map[string]string{".nvmrc": "20", "apps/web/.nvmrc": "22"},
+			"22", "apps/web/.nvmrc",
+		},
+		{
+			"version file of the root before engines of the app", "apps/web",
+			map[string]string{".nvmrc": "20", "apps/web/package.json": `{"engines": {"node": ">=18"}}`},
+			"20", ".nvmrc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			root := t.TempDir()
+			testutil.WriteFiles(t, root, tt.files)
+
+			project, err := Analyze(root, tt.dir)
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if project.NodeVersion != tt.version || project.NodeVersionSource != tt.source {
+				t.Errorf("NodeVersion = %q from %q, want %q from %q", project.NodeVersion, project.NodeVersionSource, tt.version, tt.source)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFramework(t *testing.T) {
+	tests := []struct {
+		desc      string
+		dir       string
+		files     map[string]string
+		framework string
+		outputDir string
+	}{
+		{"none", ".", nil, "", "dist"},
+		{"unknown", ".", map[string]string{"package.json": `{"dependencies": {"react": "^19.0.0"}}`}, "", "dist"},
+		{"next", ".", map[string]string{"package.json": `{"dependencies": {"next": "15.0.0"}}`}, "nextjs", "out"},
+		{"dev dependency", ".", map[string]string{"package.json": `{"devDependencies": {"react-scripts": "5.0.1"}}`}, "create-react-app", "build"},
+		{
+			"framework before vite", ".",
+			map[string]string{"package.json": `{"dependencies": {"@remix-run/react": "2.0.0"}, "devDependencies": {"vite": "5.0.0", "@remix-run/dev": "2.0.0"}}`},
+			"remix", "build/client",
+		},
+		{"vite", ".", map[string]string{"package.json": `{"devDependencies": {"vite": "5.0.0"}}`}, "vite", "dist"},
+		{
+			"package of the app in a monorepo", "apps/docs",
+			map[string]string{"package.json": `{"devDependencies": {"vite": "5.0.0"}}`, "apps/docs/package.json": `{"dependencies": {"@docusaurus/core": "3.0.0"}}`},
+			"docusaurus-2", "build",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			root := t.TempDir()
+			testutil.WriteFiles(t, root, tt.files)
+
+			project, err := Analyze(root, tt.dir)
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if project.Framework != tt.framework || project.OutputDir() != tt.outputDir {
+				t.Errorf("Framework = %q writing to %q, want %q writing to %q", project.Framework, project.OutputDir(), tt.framework, tt.outputDir)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"slark/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the config file kept at the repository root
+const FileName = ".slark.yaml"
+
+// CurrentVersion is the config schema version written by this build
+const CurrentVersion = 1
+
+// fileHeader is written above the YAML document
+const fileHeader = "# slark project configuration. Secrets are never stored in this file.\n"
+
+// Config is the persisted slark setup of a repository
+type Config struct {
+	Version      int       `yaml:"version"`
+	NotifyRunner string    `yaml:"notify_runner,omitempty"` // Runner of the notification workflow shared by every project
+	Projects     []Project `yaml:"projects"`
+}
+
+// Project is a single configured pipeline
+type Project struct {
+	Name          string            `yaml:"name"`
+	Platform      string            `yaml:"platform"`
+	DeployBranch  string            `yaml:"deploy_branch"`
+	BuildFolder   string            `yaml:"build_folder"`
+	Template      string            `yaml:"template,omitempty"`
+	Vars          map[string]string `yaml:"vars,omitempty"`
+	Framework     string            `yaml:"framework,omitempty"`
+	TeamId        string            `yaml:"team_id,omitempty"`
+	AccountId     string            `yaml:"account_id,omitempty"`
+	Preview       bool              `yaml:"preview,omitempty"`
+	Verify        bool              `yaml:"verify,omitempty"`
+	Runner        string            `yaml:"runner,omitempty"`
+	Notifications Notifications     `yaml:"notifications,omitempty"`
+	CreatedAt     time.Time         `yaml:"created_at"`
+}
+
+// Notifications holds the non-secret notification settings of a project
+type Notifications struct {
+	Telegram *Telegram `yaml:"telegram,omitempty"`
+}
+
+// Telegram holds where Telegram notifications are sent
+type Telegram struct {
+	ChatId string `yaml:"chat_id"`
+}
+
+// Load reads the config file from dir. A missing file yields an empty config.
+func Load(dir string) (*Config, error) {
+	path := filepath.Join(dir, FileName)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Config{Version: CurrentVersion}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if cfg.Version > CurrentVersion {
+		return nil, fmt.Errorf("%s has version %d, this slark only supports up to %d, please upgrade", path, cfg.Version, CurrentVersion)
+	}
+
+	return &cfg, nil
+}
+
+// Save writes the config file to dir
+func Save(dir string, cfg *Config) error {
+	path := filepath.Join(dir, FileName)
+	cfg.Version = CurrentVersion
+
+	var buf bytes.Buffer
+	buf.WriteString(fileHeader)
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Lookup returns the project matching name and branch, where an empty value
+// matches anything. It only succeeds when exactly one project matches.
+func (c *Config) Lookup(name, branch string) (Project, bool) {
+	var found []Project
+	for _, project := range c.Projects {
+		if (name == "" || project.Name == name) && (branch == "" || project.DeployBranch == branch) {
+			found = append(found, project)
+		}
+	}
+
+	if len(found) != 1 {
+		return Project{}, false
+	}
+	return found[0], true
+}
+
+// Upsert adds the project or replaces the one with the same name and branch,
+// keeping its original creation time
+func (c *Config) Upsert(project Project) {
+	for i, existing := range c.Projects {
+		if existing.Name == project.Name && existing.DeployBranch == project.DeployBranch {
+			if !existing.CreatedAt.IsZero() {
+				project.CreatedAt = existing.CreatedAt
+			}
+			c.Projects[i] = project
+			return
+		}
+	}
+
+	c.Projects = append(c.Projects, project)
+}
+
+// Remove deletes the project with the given name and branch and reports
+// whether it was found
+func (c *Config) Remove(name, branch string) bool {
+	for i, project := range c.Projects {
+		if project.Name == name && project.DeployBranch == branch {
+			c.Projects = append(c.Projects[:i], c.Projects[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// NewProject builds the persisted form of a project setup, leaving out secrets
+func NewProject(config models.ProjectConfig, platformData models.PlatformData) Project {
+	project := Project{
+		Name:         config.Name,
+		Platform:     config.Platform,
+		DeployBranch: config.DeployBranch,
+		BuildFolder:  config.BuildFolder,
+		Template:     config.Template,
+		Vars:         config.Vars,
+		Framework:    platformData.Framework,
+		AccountId:    platformData.AccountId,
+		Preview:      config.Preview,
+		Verify:       config.Verify,
+		Runner:       config.Runner,
+		CreatedAt:    config.CreatedAt.UTC().Truncate(time.Second),
+	}
+
+	// The TUI uses team_xxxx as a placeholder for "no team"
+	if platformData.TeamId != "team_xxxx" {
+		project.TeamId = platformData.TeamId
+	}
+
+	if platformData.ChatId != "" {
+		project.Notifications.Telegram = &Telegram{ChatId: platformData.ChatId}
+	}
+
+	return project
+}
+
+// PlatformData returns the non-secret platform settings of the project
+func (p Project) PlatformData() models.PlatformData {
+	platformData := models.PlatformData{
+		TeamId:    p.TeamId,
+		AccountId: p.AccountId,
+		Framework: p.Framework,
+	}
+
+	if p.Notifications.Telegram != nil {
+		platformData.ChatId = p.Notifications.Telegram.ChatId
+	}
+
+	return platformData
+}
+
+// LoadValues reads a YAML file of template variables. The file is a flat
+// mapping of variable names to values.
+func LoadValues(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file: %w", err)
+	}
+
+	var values map[string]string
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse values file %s: %w", path, err)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,342 @@
+package core
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"slark/internal/models"
+	"slark/internal/template"
+)
+
+// backupIdFormat names backups after the time they were taken, so they sort
+// by age
+const backupIdFormat = "20060102-150405"
+
+// backupsDir returns where replaced and removed workflow files are kept
+func backupsDir(projectPath string) string {
+	return filepath.Join(projectPath, ".slark", "backups")
+}
+
+// backupPath returns the directory of a backup relative to the project
+func backupPath(id string) string {
+	return filepath.Join(".slark", "backups", id)
+}
+
+// newBackupId returns the id of a backup taken now, numbered when another
+// backup was already taken within the same second
+func newBackupId(projectPath string) string {
+	id := time.Now().UTC().Format(backupIdFormat)
+
+	candidate := id
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filepath.Join(backupsDir(projectPath), candidate)); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d", id, n)
+	}
+}
+
+// backupFile copies a file of the project into the backup with the given id,
+// along with its merge base so a restored file merges like it did before.
+// Files that don't exist have nothing to back up.
+func backupFile(projectPath, id, path string) error {
+	content, err := os.ReadFile(filepath.Join(projectPath, path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := writeBackupFile(projectPath, id, path, content); err != nil {
+		return err
+	}
+
+	base, err := os.ReadFile(basePath(projectPath, path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read merge base of %s: %w", path, err)
+	}
+
+	return writeBackupFile(projectPath, id, backupBasePath(path), base)
+}
+
+// backupBasePath returns where the merge base of a file is kept in a backup,
+// relative to the backup directory
+func backupBasePath(path string) string {
+	return filepath.Join(".slark", "base", path)
+}
+
+// writeBackupFile writes content to path within the backup with the given id
+func writeBackupFile(projectPath, id, path string, content []byte) error {
+	target := filepath.Join(backupsDir(projectPath), id, path)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	if err := os.WriteFile(target, content, 0644); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// ExistingFiles returns the rendered files that would replace a different
+// file already in the project, telling slark-generated files from
+// hand-written ones
+func ExistingFiles(projectPath string, files []models.WorkflowFile) ([]models.ExistingFile, error) {
+	var existing []models.ExistingFile
+	for _, file := range files {
+		if file.Status != "modified" {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(projectPath, file.Path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Path, err)
+		}
+
+		existingFile := models.ExistingFile{Path: file.Path}
+		if meta, ok := ParseWorkflowHeader(string(content)); ok {
+			existingFile.Generated = true
+			if meta.Kind == "deploy" {
+				existingFile.Owner = fmt.Sprintf("%s on %s", meta.Name, meta.DeployBranch)
+			}
+		}
+		existing = append(existing, existingFile)
+	}
+
+	return existing, nil
+}
+
+// OverwriteError is returned when generating workflows would replace files
+// that are already in the project and overwriting was not allowed
+type OverwriteError struct {
+	Files []models.ExistingFile
+}
+
+func (e *OverwriteError) Error() string {
+	var paths []string
+	for _, file := range e.Files {
+		paths = append(paths, fmt.Sprintf("%s (%s)", file.Path, DescribeExistingFile(file)))
+	}
+
+	return fmt.Sprintf("existing workflow files would be overwritten: %s; rerun with --force to replace them, the current versions are backed up to .slark/backups",
+		strings.Join(paths, ", "))
+}
+
+// DescribeExistingFile tells where an existing workflow file comes from
+func DescribeExistingFile(file models.ExistingFile) string {
+	switch {
+	case !file.Generated:
+		return "hand-written"
+	case file.Owner != "":
+		return "generated by slark for " + file.Owner
+	default:
+		return "generated by slark"
+	}
+}
+
+// ListBackups returns the backups of the project, newest first
+func ListBackups(projectPath string) ([]models.Backup, error) {
+	entries, err := os.ReadDir(backupsDir(projectPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backups: %w", err)
+	}
+
+	var backups []models.Backup
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		files, err := backupFiles(projectPath, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, models.Backup{Id: entry.Name(), Files: files})
+	}
+
+	slices.Reverse(backups)
+	return backups, nil
+}
+
+// backupFiles returns the project paths of the files in a backup, leaving
+// out their merge bases
+func backupFiles(projectPath, id string) ([]string, error) {
+	dir := filepath.Join(backupsDir(projectPath), id)
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if rel == ".slark" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup %s: %w", id, err)
+	}
+
+	return files, nil
+}
+
+// RestoreBackup copies the files of a backup back into the project. "latest"
+// restores the newest backup. The files being replaced are backed up first,
+// so a restore can be undone. The merge bases of the restored files are
+// restored too, so `slark update` merges the templates into them without
+// conflicts. It returns the restored backup and the id of the backup of the
+// replaced files, empty when nothing was replaced.
+func RestoreBackup(projectPath, id string, opts models.GenerateOptions) (models.Backup, string, error) {
+	backups, err := ListBackups(projectPath)
+	if err != nil {
+		return models.Backup{}, "", err
+	}
+
+	if id == "latest" && len(backups) > 0 {
+		id = backups[0].Id
+	}
+
+	index := slices.IndexFunc(backups, func(b models.Backup) bool { return b.Id == id })
+	if index < 0 {
+		return models.Backup{}, "", fmt.Errorf("backup %s not found, run `slark restore` to list backups", id)
+	}
+	backup := backups[index]
+
+	if opts.DryRun {
+		return backup, "", nil
+	}
+
+	// Keep the current versions unless they already match the backup
+	replacedId := ""
+	for _, path := range backup.Files {
+		saved, err := os.ReadFile(filepath.Join(backupsDir(projectPath), backup.Id, path))
+		if err != nil {
+			return backup, "", fmt.Errorf("failed to read backup of %s: %w", path, err)
+		}
+
+		current, err := os.ReadFile(filepath.Join(projectPath, path))
+		if err != nil && !os.IsNotExist(err) {
+			return backup, "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if err == nil && string(current) == string(saved) {
+			if err := restoreBase(projectPath, backup.Id, path, saved); err != nil {
+				return backup, "", err
+			}
+			continue
+		}
+
+		if err == nil {
+			if replacedId == "" {
+				replacedId = newBackupId(projectPath)
+			}
+			if err := backupFile(projectPath, replacedId, path); err != nil {
+				return backup, "", err
+			}
+		}
+
+		target := filepath.Join(projectPath, path)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return backup, "", fmt.Errorf("failed to create %s directory: %w", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(target, saved, 0644); err != nil {
+			return backup, "", fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+
+		if err := restoreBase(projectPath, backup.Id, path, saved); err != nil {
+			return backup, "", err
+		}
+	}
+
+	return backup, replacedId, nil
+}
+
+// restoreBase puts back the merge base of a restored file. Backups taken
+// before merge bases were backed up have none, the base is then generated
+// again from the header of the restored file.
+func restoreBase(projectPath, id, path string, content []byte) error {
+	base, err := os.ReadFile(filepath.Join(backupsDir(projectPath), id, backupBasePath(path)))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read backup of the merge base of %s: %w", path, err)
+	}
+
+	if os.IsNotExist(err) {
+		file, err := models.WorkflowFile{}, fmt.Errorf("%s was not generated by slark", path)
+		if meta, ok := ParseWorkflowHeader(string(content)); ok {
+			file, err = regenerateWorkflow(template.NewLibrary(projectPath), projectPath, meta)
+		}
+
+		// The base of the replaced file does not belong to the restored one
+		if err != nil {
+			if err := os.Remove(basePath(projectPath, path)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove merge base of %s: %w", path, err)
+			}
+			return nil
+		}
+		base = []byte(file.Content)
+	}
+
+	return writeBaseFile(projectPath, models.WorkflowFile{Path: path, Content: string(base)})
+}
+
+// FormatBackups builds a human readable list of backups
+func FormatBackups(backups []models.Backup) string {
+	if len(backups) == 0 {
+		return "No backups found.\n"
+	}
+
+	var b strings.Builder
+	for _, backup := range backups {
+		fmt.Fprintf(&b, "%s\n", backup.Id)
+		for _, path := range backup.Files {
+			fmt.Fprintf(&b, "  %s\n", path)
+		}
+	}
+	b.WriteString("\nRestore one with: slark restore <backup>\n")
+
+	return b.String()
+}
+
+// FormatRestore builds a human readable summary of a restored backup
+func FormatRestore(backup models.Backup, replacedId string, opts models.GenerateOptions) string {
+	var b strings.Builder
+
+	verb := "Restored"
+	if opts.DryRun {
+		b.WriteString("Dry run: nothing was restored.\n\n")
+		verb = "Would restore"
+	}
+
+	fmt.Fprintf(&b, "%s backup %s:\n", verb, backup.Id)
+	for _, path := range backup.Files {
+		fmt.Fprintf(&b, "- %s\n", path)
+	}
+
+	if replacedId != "" {
+		fmt.Fprintf(&b, "\nThe replaced files were backed up to %s, run `slark restore %s` to undo.\n",
+			backupPath(replacedId), replacedId)
+	}
+
+	return b.String()
+}
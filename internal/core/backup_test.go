@@ -0,0 +1,169 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"slark/internal/models"
+)
+
+func TestBackupFile(t *testing.T) {
+	projectPath := t.TempDir()
+	writePipeline(t, projectPath, models.ProjectConfig{Name: "web", DeployBranch: "main", BuildFolder: ".", Platform: "vercel"}, false)
+	path := filepath.Join(workflowsDir, "web.main.yml")
+
+	id := newBackupId(projectPath)
+	if err := backupFile(projectPath, id, path); err != nil {
+		t.Fatalf("backupFile: %v", err)
+	}
+	if err := backupFile(projectPath, id, filepath.Join(workflowsDir, "missing.yml")); err != nil {
+		t.Fatalf("backupFile of a missing file: %v", err)
+	}
+
+	backups, err := ListBackups(projectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || backups[0].Id != id || !slices.Equal(backups[0].Files, []string{path}) {
+		t.Errorf("backups = %+v, want %s with %s only", backups, id, path)
+	}
+	if !exists(projectPath, filepath.Join(backupPath(id), backupBasePath(path))) {
+		t.Error("the merge base was not backed up")
+	}
+
+	// A backup taken within the same second gets its own directory
+	if next := newBackupId(projectPath); next == id {
+		t.Errorf("newBackupId reused %s", id)
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	tests := []struct {
+		desc     string
+		keepBase bool // The backup holds the merge base
+	}{
+		{"with merge base", true},
+		{"backup without merge base", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			projectPath := t.TempDir()
+			writePipeline(t, projectPath, models.ProjectConfig{Name: "web", DeployBranch: "main", BuildFolder: ".", Platform: "vercel"}, false)
+			path := filepath.Join(workflowsDir, "web.main.yml")
+			opts := models.GenerateOptions{ProjectPath: projectPath}
+
+			// Edit the workflow locally, then remove the pipeline
+			generated, err := os.ReadFile(filepath.Join(projectPath, path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			edited := string(generated) + "# Local edit\n"
+			if err := os.WriteFile(filepath.Join(projectPath, path), []byte(edited), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			removed, err := RemovePipeline("web", "main", models.PlatformData{}, nil, false, opts)
+			if err != nil {
+				t.Fatalf("RemovePipeline: %v", err)
+			}
+			if !tt.keepBase {
+				if err := os.RemoveAll(filepath.Join(projectPath, backupPath(removed.Backup), ".slark")); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			backup, replacedId, err := RestoreBackup(projectPath, "latest", opts)
+			if err != nil {
+				t.Fatalf("RestoreBackup: %v", err)
+			}
+			if backup.Id != removed.Backup || replacedId != "" {
+				t.Errorf("restored %s replacing %q, want %s replacing nothing", backup.Id, replacedId, removed.Backup)
+			}
+
+			content, err := os.ReadFile(filepath.Join(projectPath, path))
+			if err != nil || string(content) != edited {
+				t.Fatalf("restored content = %q, %v, want the local edit", content, err)
+			}
+			base, err := os.ReadFile(basePath(projectPath, path))
+			if err != nil || string(base) != string(generated) {
+				t.Errorf("restored merge base = %q, %v, want the generated workflow", base, err)
+			}
+
+			// The update merges against the restored base and keeps the edit
+			results, err := UpdateWorkflows(opts)
+			if err != nil {
+				t.Fatalf("UpdateWorkflows: %v", err)
+			}
+			if len(results) != 1 || results[0].Status != "unchanged" || results[0].Conflicts != 0 {
+				t.Errorf("update results = %+v, want web unchanged", results)
+			}
+			if content, _ := os.ReadFile(filepath.Join(projectPath, path)); string(content) != edited {
+				t.Errorf("the update dropped the local edit: %q", content)
+			}
+		})
+	}
+}
+
+func TestRestoreBackupReplacesFiles(t *testing.T) {
+	projectPath := t.TempDir()
+	writePipeline(t, projectPath, models.ProjectConfig{Name: "web", DeployBranch: "main", BuildFolder: ".", Platform: "vercel"}, false)
+	path := filepath.Join(workflowsDir, "web.main.yml")
+	opts := models.GenerateOptions{ProjectPath: projectPath}
+
+	generated, err := os.ReadFile(filepath.Join(projectPath, path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := newBackupId(projectPath)
+	if err := backupFile(projectPath, id, path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace the workflow and its base with another version
+	if err := os.WriteFile(filepath.Join(projectPath, path), []byte("name: other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeBaseFile(projectPath, models.WorkflowFile{Path: path, Content: "name: other base\n"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A dry run changes nothing
+	if _, replacedId, err := RestoreBackup(projectPath, id, models.GenerateOptions{ProjectPath: projectPath, DryRun: true}); err != nil || replacedId != "" {
+		t.Fatalf("RestoreBackup dry run = %q, %v", replacedId, err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(projectPath, path)); string(content) != "name: other\n" {
+		t.Fatalf("dry run restored %s", path)
+	}
+
+	_, replacedId, err := RestoreBackup(projectPath, id, opts)
+	if err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+	if replacedId == "" {
+		t.Fatal("the replaced file was not backed up")
+	}
+	if content, _ := os.ReadFile(filepath.Join(projectPath, path)); string(content) != string(generated) {
+		t.Errorf("restored content = %q", content)
+	}
+	if base, _ := os.ReadFile(basePath(projectPath, path)); string(base) != string(generated) {
+		t.Errorf("restored merge base = %q", base)
+	}
+
+	// Restoring the replaced files undoes the restore, base included
+	if _, _, err := RestoreBackup(projectPath, replacedId, opts); err != nil {
+		t.Fatalf("RestoreBackup undo: %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(projectPath, path)); string(content) != "name: other\n" {
+		t.Errorf("undone content = %q", content)
+	}
+	if base, _ := os.ReadFile(basePath(projectPath, path)); string(base) != "name: other base\n" {
+		t.Errorf("undone merge base = %q", base)
+	}
+
+	if _, _, err := RestoreBackup(projectPath, "missing", opts); err == nil {
+		t.Error("restoring a missing backup succeeded")
+	}
+}
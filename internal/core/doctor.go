@@ -0,0 +1,273 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"slark/internal/config"
+	"slark/internal/models"
+	"slark/internal/platform"
+	"slark/internal/template"
+	"slark/internal/utils"
+)
+
+// Doctor check statuses
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+// RunDoctor runs the pre-flight diagnostics for the project. The Vercel checks
+// are skipped when no API token is given.
+func RunDoctor(projectPath string, platformData models.PlatformData) []models.Check {
+	var checks []models.Check
+
+	checks = append(checks, checkGitRepository(projectPath))
+	checks = append(checks, checkGitRemote(projectPath))
+	checks = append(checks, checkWorkflowsWritable(projectPath))
+	checks = append(checks, checkConfigFile(projectPath))
+	checks = append(checks, checkWorkflows(projectPath)...)
+	checks = append(checks, checkVercel(platformData)...)
+
+	return checks
+}
+
+// checkGitRepository makes sure the project is a git repository
+func checkGitRepository(projectPath string) models.Check {
+	check := models.Check{Name: "git repository"}
+
+	if !utils.IsGitRepository(projectPath) {
+		check.Status = CheckFail
+		check.Detail = "not inside a git repository"
+		check.Hint = "run `git init` or point --project-path at a repository"
+		return check
+	}
+
+	check.Status = CheckPass
+	check.Detail = projectPath
+	return check
+}
+
+// checkGitRemote makes sure the origin remote points at GitHub
+func checkGitRemote(projectPath string) models.Check {
+	check := models.Check{Name: "github remote"}
+
+	repo, err := utils.GetGitHubRepoInfo(projectPath)
+	if err != nil {
+		check.Status = CheckWarn
+		check.Detail = err.Error()
+		check.Hint = "add a GitHub remote, e.g. `git remote add origin git@github.com:owner/repo.git`"
+		return check
+	}
+
+	check.Status = CheckPass
+	check.Detail = repo
+	return check
+}
+
+// checkWorkflowsWritable makes sure workflow files can be written
+func checkWorkflowsWritable(projectPath string) models.Check {
+	check := models.Check{Name: "workflows directory"}
+
+	// Probe the closest existing directory, slark creates the rest
+	dir := filepath.Join(projectPath, workflowsDir)
+	for {
+		if _, err := os.Stat(dir); err == nil || dir == projectPath {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	probe, err := os.CreateTemp(dir, ".slark-doctor-*")
+	if err != nil {
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("%s is not writable", dir)
+		check.Hint = "fix the permissions of the directory or run slark as its owner"
+		return check
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	check.Status = CheckPass
+	check.Detail = fmt.Sprintf("%s is writable", workflowsDir)
+	return check
+}
+
+// checkConfigFile makes sure the config file, when present, can be read
+func checkConfigFile(projectPath string) models.Check {
+	check := models.Check{Name: config.FileName}
+
+	cfg, err := config.Load(projectPath)
+	if err != nil {
+		check.Status = CheckFail
+		check.Detail = err.Error()
+		check.Hint = fmt.Sprintf("fix or remove %s and rerun `slark init`", config.FileName)
+		return check
+	}
+
+	check.Status = CheckPass
+	check.Detail = fmt.Sprintf("%d project(s) configured", len(cfg.Projects))
+	return check
+}
+
+// checkWorkflows verifies every slark-generated workflow can be regenerated
+// and that the files it depends on are present
+func checkWorkflows(projectPath string) []models.Check {
+	paths, err := findWorkflowFiles(projectPath)
+	if err != nil {
+		return []models.Check{{
+			Name:   "workflows",
+			Status: CheckFail,
+			Detail: err.Error(),
+			Hint:   "make sure the workflows directory is readable",
+		}}
+	}
+
+	cfg, err := config.Load(projectPath)
+	if err != nil {
+		cfg = &config.Config{}
+	}
+
+	lib := template.NewLibrary(projectPath)
+
+	var checks []models.Check
+	for _, path := range paths {
+		content, err := os.ReadFile(filepath.Join(projectPath, path))
+		if err != nil {
+			checks = append(checks, models.Check{Name: path, Status: CheckFail, Detail: err.Error()})
+			continue
+		}
+
+		meta, ok := ParseWorkflowHeader(string(content))
+		if !ok {
+			continue
+		}
+
+		checks = append(checks, checkWorkflow(lib, projectPath, path, meta, cfg))
+	}
+
+	if len(checks) == 0 {
+		checks = append(checks, models.Check{
+			Name:   "workflows",
+			Status: CheckPass,
+			Detail: "no slark-generated workflows yet",
+		})
+	}
+
+	return checks
+}
+
+// checkWorkflow verifies a single slark-generated workflow
+func checkWorkflow(lib *template.Library, projectPath, path string, meta models.WorkflowMeta, cfg *config.Config) models.Check {
+	check := models.Check{Name: path}
+	var problems, hints []string
+
+	if _, err := regenerateWorkflow(lib, projectPath, meta); err != nil {
+		problems = append(problems, err.Error())
+		hints = append(hints, "restore the `# slark:` header lines or regenerate with `slark init`")
+	}
+
+	if _, err := os.Stat(basePath(projectPath, path)); err != nil {
+		problems = append(problems, "merge base is missing, `slark update` will report every local edit as a conflict")
+		hints = append(hints, "commit the .slark directory together with the workflows")
+	}
+
+	if meta.Kind == "deploy" {
+		if meta.Notify {
+			if _, err := os.Stat(filepath.Join(projectPath, workflowsDir, ".telegram-noti.yml")); err != nil {
+				problems = append(problems, "notifications are enabled but .telegram-noti.yml is missing")
+				hints = append(hints, "rerun `slark init` with the Telegram settings")
+			}
+		}
+
+		if _, ok := cfg.Lookup(meta.Name, meta.DeployBranch); !ok {
+			problems = append(problems, fmt.Sprintf("project is not recorded in %s", config.FileName))
+			hints = append(hints, "rerun `slark init` for the project to record its settings")
+		}
+	}
+
+	if len(problems) == 0 {
+		check.Status = CheckPass
+		check.Detail = "consistent"
+		return check
+	}
+
+	check.Status = CheckWarn
+	check.Detail = strings.Join(problems, "; ")
+	check.Hint = strings.Join(hints, "; ")
+	return check
+}
+
+// checkVercel verifies the Vercel API token and team
+func checkVercel(platformData models.PlatformData) []models.Check {
+	tokenCheck := models.Check{Name: "vercel token"}
+
+	if platformData.ApiKey == "" {
+		tokenCheck.Status = CheckWarn
+		tokenCheck.Detail = "skipped, no token given"
+		tokenCheck.Hint = "pass --vercel-token or set $VERCEL_TOKEN to verify it"
+		return []models.Check{tokenCheck}
+	}
+
+	user, err := platform.GetVercelUser(platformData.ApiKey)
+	if err != nil {
+		tokenCheck.Status = CheckFail
+		tokenCheck.Detail = err.Error()
+		tokenCheck.Hint = "create a new token at https://vercel.com/account/tokens"
+		return []models.Check{tokenCheck}
+	}
+
+	tokenCheck.Status = CheckPass
+	tokenCheck.Detail = "authenticated as " + user.Username
+
+	if platformData.TeamId == "" {
+		return []models.Check{tokenCheck}
+	}
+
+	teamCheck := models.Check{Name: "vercel team"}
+
+	team, err := platform.GetVercelTeam(platformData.ApiKey, platformData.TeamId)
+	if err != nil {
+		teamCheck.Status = CheckFail
+		teamCheck.Detail = err.Error()
+		teamCheck.Hint = "check the team ID in the Vercel team settings and that the token has access to it"
+		return []models.Check{tokenCheck, teamCheck}
+	}
+
+	teamCheck.Status = CheckPass
+	teamCheck.Detail = fmt.Sprintf("%s (%s)", team.Name, team.Id)
+	return []models.Check{tokenCheck, teamCheck}
+}
+
+// FormatChecks renders doctor checks as a table followed by remediation hints
+func FormatChecks(checks []models.Check) string {
+	var b strings.Builder
+
+	width := len("CHECK")
+	for _, check := range checks {
+		width = max(width, len(check.Name))
+	}
+
+	fmt.Fprintf(&b, "%-6s %-*s %s\n", "STATUS", width, "CHECK", "DETAIL")
+	for _, check := range checks {
+		fmt.Fprintf(&b, "%-6s %-*s %s\n", strings.ToUpper(check.Status), width, check.Name, check.Detail)
+	}
+
+	var hints []string
+	for _, check := range checks {
+		if check.Status != CheckPass && check.Hint != "" {
+			hints = append(hints, fmt.Sprintf("- %s: %s", check.Name, check.Hint))
+		}
+	}
+
+	if len(hints) > 0 {
+		b.WriteString("\nHow to fix:\n")
+		b.WriteString(strings.Join(hints, "\n"))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
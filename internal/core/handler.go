@@ -1,8 +1,11 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"slark/internal/actions"
+	"slark/internal/config"
 	"slark/internal/models"
 	"strings"
 
@@ -33,6 +36,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 
+		// Existing files are only replaced once confirmed
+		if m.Stage == 3 {
+			switch msg.String() {
+			case "y", "Y":
+				m.Stage = 1
+				return m, tea.Batch(m.Spinner.Tick, m.Overwrite)
+			case "n", "N", "enter":
+				m.Stage = 2
+				m.Success = false
+				m.Err = fmt.Errorf("overwriting was declined, no files were changed")
+				return m, nil
+			}
+			return m, nil
+		}
+
 	case tea.WindowSizeMsg:
 		m.Width = msg.Width
 		m.Height = msg.Height
@@ -42,6 +60,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case models.ProcessFinishedMsg:
+		// Ask before replacing existing workflow files
+		var overwrite *OverwriteError
+		if errors.As(msg.Err, &overwrite) {
+			m.Stage = 3
+			m.Existing = overwrite.Files
+			return m, nil
+		}
+
 		// Processing finished
 		m.Stage = 2
 		m.Success = msg.Success
@@ -66,49 +92,79 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			// Check if the form has been completed
 			if m.Form.State == huh.StateCompleted {
-				projectName := m.Form.GetString("projectName")
-				deployBranch := m.Form.GetString("deployBranch")
-				buildFolder := m.Form.GetString("buildFolder")
-				platform := m.Form.GetString("platform")
+				config := models.ProjectConfig{
+					Name:         m.Form.GetString("projectName"),
+					DeployBranch: m.Form.GetString("deployBranch"),
+					BuildFolder:  m.Form.GetString("buildFolder"),
+					Platform:     m.Form.GetString("platform"),
+					Template:     m.Form.GetString("template"),
+					Vars:         m.Vars,
+					Preview:      m.Form.GetBool("preview"),
+					Verify:       m.Form.GetBool("verify"),
+					Runner:       m.Form.GetString("runner"),
+				}
+				dryRun := m.Form.GetBool("dryRun")
+
+				// Use the token of the platform the project is deployed to
+				apiKey := m.Form.GetString("vercelToken")
+				if config.Platform == "cloudflare" {
+					apiKey = m.Form.GetString("cloudflareToken")
+				}
 
 				// Create a single platformData with all fields
 				platformData := models.PlatformData{
-					ApiKey:    m.Form.GetString("vercelToken"),
+					ApiKey:    apiKey,
 					TeamId:    m.Form.GetString("vercelTeamName"),
+					AccountId: m.Form.GetString("cloudflareAccountId"),
 					BotToken:  m.Form.GetString("telegramToken"),
 					ChatId:    m.Form.GetString("telegramChatId"),
 					Framework: m.Form.GetString("framework"),
 				}
 
 				// Set default values if empty
-				if projectName == "" {
-					projectName = "slark"
+				if config.Name == "" {
+					config.Name = "slark"
 				}
 
-				if deployBranch == "" {
-					deployBranch = "main"
+				if config.DeployBranch == "" {
+					config.DeployBranch = "main"
 				}
 
-				if buildFolder == "" {
-					buildFolder = "./"
+				if config.BuildFolder == "" {
+					config.BuildFolder = "./"
 				}
 
-				if platform == "" {
-					platform = "vercel"
+				if config.Platform == "" {
+					config.Platform = "vercel"
+				}
+
+				if config.Template == "" {
+					config.Template = DefaultTemplate
 				}
 
 				if platformData.TeamId == "" {
 					platformData.TeamId = "team_xxxx"
 				}
 
-				if platformData.ChatId == "" {
+				// The chat ID placeholder only applies once a bot token is given
+				if platformData.ChatId == "" && platformData.BotToken != "" {
 					platformData.ChatId = "-100"
 				}
 
+				opts := models.GenerateOptions{
+					ProjectPath: m.ProjectPath,
+					DryRun:      dryRun,
+				}
+
+				// Kept to run again once overwriting existing files is confirmed
+				forced := opts
+				forced.Force = true
+				m.Overwrite = ProcessProject(config, platformData, forced)
+
 				m.Stage = 1
 				return m, tea.Batch(
 					m.Spinner.Tick,
-					ProcessProject(projectName, deployBranch, buildFolder, platform, platformData),
+					ProcessProject(config, platformData, opts),
 				)
 			}
 		}
@@ -127,6 +183,8 @@ func (m Model) View() string {
 		return b.String()
 	} else if m.Stage == 1 {
 		return m.ProcessingView()
+	} else if m.Stage == 3 {
+		return m.OverwriteView()
 	} else {
 		return m.ResultsView()
 	}
@@ -136,6 +194,17 @@ func (m Model) ProcessingView() string {
 	return fmt.Sprintf("\n  %s Setting up your project...\n\n  This won't take long.", m.Spinner.View())
 }
 
+func (m Model) OverwriteView() string {
+	var b strings.Builder
+	b.WriteString("\n  These workflow files already exist and would be overwritten:\n\n")
+	for _, file := range m.Existing {
+		b.WriteString(fmt.Sprintf("  - %s (%s)\n", file.Path, DescribeExistingFile(file)))
+	}
+	b.WriteString("\n  The current versions are backed up to .slark/backups.\n\n")
+	b.WriteString(helpStyle.Render("  Overwrite them? y to overwrite, n to cancel"))
+	return b.String()
+}
+
 func (m Model) ResultsView() string {
 	if m.Success {
 		return fmt.Sprintf("\n%s\n\n%s\n\n%s",
@@ -155,15 +224,25 @@ func (m Model) ResultsView() string {
 		helpStyle.Render("Press Enter to exit"))
 }
 
-func InitialModel() Model {
+func InitialModel(projectPath string) Model {
 	// Setup spinner
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("86"))
 
+	// Pre-fill the form from the saved project setup when there is one
+	cfg, err := config.Load(projectPath)
+	if err != nil {
+		slog.Warn("ignoring unreadable config", "error", err)
+		cfg = &config.Config{}
+	}
+	saved, _ := cfg.Lookup("", "")
+	savedPlatformData := saved.PlatformData()
+
 	// Create a form with custom styling for each field
 	projectNameInput := huh.NewInput().
 		Key("projectName").
+		Value(&saved.Name).
 		Title("Project Name").
 		Placeholder("slark").
 		Validate(func(s string) error {
@@ -175,16 +254,19 @@ func InitialModel() Model {
 
 	deployBranchInput := huh.NewInput().
 		Key("deployBranch").
+		Value(&saved.DeployBranch).
 		Title("Deploy Branch").
 		Placeholder("main")
 
 	buildFolderInput := huh.NewInput().
 		Key("buildFolder").
+		Value(&saved.BuildFolder).
 		Title("Build Folder").
 		Placeholder("")
 
 	platformSelect := huh.NewSelect[string]().
 		Key("platform").
+		Value(&saved.Platform).
 		Title("Deployment Platform").
 		Options(
 			huh.NewOption("Vercel", "vercel"),
@@ -192,9 +274,74 @@ func InitialModel() Model {
 			// huh.NewOption("GitHub Pages", "github-pages"),
 		)
 
+	// Offer the variants of the selected platform, including project and user templates
+	templateSelect := huh.NewSelect[string]().
+		Key("template").
+		Value(&saved.Template).
+		Title("Workflow Template").
+		OptionsFunc(func() []huh.Option[string] {
+			variants, err := TemplateVariants(projectPath, saved.Platform)
+			if err != nil {
+				slog.Warn("failed to list templates", "error", err)
+			}
+
+			var options []huh.Option[string]
+			for _, variant := range variants {
+				name := strings.TrimPrefix(variant.Path, saved.Platform+"/")
+				label := variant.Name
+				if variant.Description != "" {
+					label += " - " + variant.Description
+				}
+				options = append(options, huh.NewOption(label, name))
+			}
+
+			if len(options) == 0 {
+				options = append(options, huh.NewOption("Basic", DefaultTemplate))
+			}
+			return options
+		}, &saved.Platform)
+
+	runnerInput := huh.NewInput().
+		Key("runner").
+		Value(&saved.Runner).
+		Title("Runner").
+		Description("A label, labels separated by commas or group:name, empty uses the template's runner").
+		Placeholder("ubuntu-latest").
+		Validate(func(s string) error {
+			if s == "" {
+				return nil
+			}
+			_, err := actions.ParseRunsOn(s)
+			return err
+		})
+
+	previewConfirm := huh.NewConfirm().
+		Key("preview").
+		Value(&saved.Preview).
+		Title("Preview Deployments").
+		Description("Deploy every pull request to a preview URL and post it as a comment").
+		Affirmative("Yes").
+		Negative("No")
+
+	verifyConfirm := huh.NewConfirm().
+		Key("verify").
+		Value(&saved.Verify).
+		Title("Verify Before Deploying").
+		Description("Run the lint, typecheck and test scripts in a job the deployment waits for").
+		Affirmative("Yes").
+		Negative("No")
+
+	dryRunConfirm := huh.NewConfirm().
+		Key("dryRun").
+		Title("Dry Run").
+		Description("Preview the generated files without writing them or creating the project").
+		Affirmative("Preview").
+		Negative("Apply")
+
 	vercelProjectInput := huh.NewGroup(
 		huh.NewInput().
 			Key("vercelTeamName").
+			Value(&savedPlatformData.TeamId).
 			Title("Your Vercel Team ID").
 			Placeholder("team_xxxx"),
 		huh.NewInput().
@@ -203,6 +350,7 @@ func InitialModel() Model {
 			EchoMode(huh.EchoModePassword),
 		huh.NewSelect[string]().
 			Key("framework").
+			Value(&savedPlatformData.Framework).
 			Title("Framework").
 			Filtering(true).
 			Height(5).
@@ -255,10 +403,31 @@ func InitialModel() Model {
 				huh.NewOption("Sanity", "sanity"),
 				huh.NewOption("Storybook", "storybook"),
 			),
-	)
+	).WithHideFunc(func() bool {
+		return saved.Platform != "" && saved.Platform != "vercel"
+	})
+	cloudflareProjectInput := huh.NewGroup(
+		huh.NewInput().
+			Key("cloudflareAccountId").
+			Value(&savedPlatformData.AccountId).
+			Title("Your Cloudflare Account ID").
+			Validate(func(s string) error {
+				if s == "" {
+					return fmt.Errorf("account ID cannot be empty")
+				}
+				return nil
+			}),
+		huh.NewInput().
+			Key("cloudflareToken").
+			Title("Your Cloudflare API Token").
+			EchoMode(huh.EchoModePassword),
+	).WithHideFunc(func() bool {
+		return saved.Platform != "cloudflare"
+	})
 	telegramInput := huh.NewGroup(
 		huh.NewInput().
 			Key("telegramChatId").
+			Value(&savedPlatformData.ChatId).
 			Title("Your Telegram Chat ID").
 			Placeholder("-100"),
 		huh.NewInput().
@@ -272,16 +441,24 @@ func InitialModel() Model {
 			deployBranchInput,
 			buildFolderInput,
 			platformSelect,
+			templateSelect,
+			runnerInput,
+			previewConfirm,
+			verifyConfirm,
+			dryRunConfirm,
 		),
 		vercelProjectInput,
+		cloudflareProjectInput,
 		telegramInput,
 	).WithShowHelp(true)
 
 	return Model{
 		models.Model{
-			Form:    form,
-			Spinner: s,
-			Stage:   0,
+			Form:        form,
+			Vars:        saved.Vars,
+			Spinner:     s,
+			Stage:       0,
+			ProjectPath: projectPath,
 		},
 	}
 }
@@ -2,52 +2,138 @@ package core
 
 import (
 	"fmt"
+	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
 
+	"slark/internal/actions"
+	"slark/internal/config"
 	"slark/internal/models"
+	"slark/internal/workspace"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 // SetupProject handles the core project setup logic
-// It validates inputs, creates necessary project configurations,
+// It validates the project settings, completes them with defaults
 // and prepares everything needed for generating workflows
-func SetupProject(projectName, deployBranch, buildFolder, platform string) (models.ProjectConfig, error) {
+func SetupProject(config models.ProjectConfig) (models.ProjectConfig, error) {
+	// The workflow header doesn't keep surrounding spaces
+	config.Name = strings.TrimSpace(config.Name)
+	config.DeployBranch = strings.TrimSpace(config.DeployBranch)
+
 	// Validate project inputs
-	if err := validateProjectInputs(projectName, platform); err != nil {
+	if err := validateProjectInputs(config); err != nil {
 		return models.ProjectConfig{}, err
 	}
 
-	// Clean up build folder path
-	buildFolder = filepath.Clean(buildFolder)
+	// Keep the runner in its canonical form
+	if config.Runner != "" {
+		runsOn, err := actions.ParseRunsOn(config.Runner)
+		if err != nil {
+			return models.ProjectConfig{}, err
+		}
+		config.Runner = runsOn.String()
+	}
 
-	// Create project configuration
-	config := models.ProjectConfig{
-		Name:         projectName,
-		DeployBranch: deployBranch,
-		BuildFolder:  buildFolder,
-		Platform:     platform,
-		CreatedAt:    time.Now(),
+	if config.Template == "" {
+		config.Template = DefaultTemplate
 	}
 
+	// Clean up build folder path
+	config.BuildFolder = filepath.Clean(config.BuildFolder)
+	config.CreatedAt = time.Now()
+
 	return config, nil
 }
 
+// ResolveProjectPath returns the absolute path of the project directory,
+// making sure it exists
+func ResolveProjectPath(projectPath string) (string, error) {
+	if projectPath == "" {
+		projectPath = "."
+	}
+
+	absPath, err := filepath.Abs(projectPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid project path %s: %w", projectPath, err)
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid project path: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("project path %s is not a directory", absPath)
+	}
+
+	return absPath, nil
+}
+
+// DiscoverApps returns the folders matching pattern that hold an app, a
+// package.json, relative to the project. Apps are named after their folder,
+// so two folders with the same name are an error.
+func DiscoverApps(projectPath, pattern string) ([]string, error) {
+	dirs, err := workspace.Glob(projectPath, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no folder matching %s holds a package.json", pattern)
+	}
+
+	named := make(map[string]string)
+	for _, dir := range dirs {
+		name := path.Base(dir)
+		if other, ok := named[name]; ok {
+			return nil, fmt.Errorf("apps %s and %s would both be named %s, narrow the pattern to set up one of them", other, dir, name)
+		}
+		named[name] = dir
+	}
+
+	return dirs, nil
+}
+
 // validateProjectInputs performs validation on required project inputs
-func validateProjectInputs(projectName, platform string) error {
-	if projectName == "" {
+func validateProjectInputs(config models.ProjectConfig) error {
+	if config.Name == "" {
 		return fmt.Errorf("project name cannot be empty")
 	}
 
+	// Settings are recorded one per line in the workflow header
+	for _, field := range []struct{ name, value string }{
+		{"project name", config.Name},
+		{"deploy branch", config.DeployBranch},
+		{"build folder", config.BuildFolder},
+	} {
+		if strings.IndexFunc(field.value, unicode.IsControl) >= 0 {
+			return fmt.Errorf("%s %q cannot contain newlines or control characters", field.name, field.value)
+		}
+	}
+
+	// The build folder must be inside the repository
+	folder := filepath.ToSlash(filepath.Clean(config.BuildFolder))
+	if filepath.IsAbs(config.BuildFolder) || path.IsAbs(folder) || folder == ".." || strings.HasPrefix(folder, "../") {
+		return fmt.Errorf("build folder %s must be a relative path inside the repository", config.BuildFolder)
+	}
+
 	validPlatforms := map[string]bool{
 		"vercel":     true,
 		"cloudflare": true,
 	}
 
-	if !validPlatforms[platform] {
-		return fmt.Errorf("unsupported platform: %s", platform)
+	if !validPlatforms[config.Platform] {
+		return fmt.Errorf("unsupported platform: %s", config.Platform)
+	}
+
+	// Variants are looked up inside the platform's template directory
+	if strings.ContainsAny(config.Template, "/\\") {
+		return fmt.Errorf("invalid template %s, expected a variant name such as basic or advanced", config.Template)
 	}
 
 	return nil
@@ -55,13 +141,9 @@ func validateProjectInputs(projectName, platform string) error {
 
 // ProcessProject is the main function that processes project setup and returns a tea.Cmd
 // It's used by the TUI to handle the asynchronous project setup process
-func ProcessProject(projectName, deployBranch, buildFolder, platform string, platformData models.PlatformData) tea.Cmd {
+func ProcessProject(config models.ProjectConfig, platformData models.PlatformData, opts models.GenerateOptions) tea.Cmd {
 	return func() tea.Msg {
-		// Initialize result builder
-		var resultBuilder strings.Builder
-
-		// Setup project
-		config, err := SetupProject(projectName, deployBranch, buildFolder, platform)
+		result, err := RunProject(config, platformData, opts)
 		if err != nil {
 			return models.ProcessFinishedMsg{
 				Success: false,
@@ -70,33 +152,204 @@ func ProcessProject(projectName, deployBranch, buildFolder, platform string, pla
 			}
 		}
 
-		// Generate workflows based on platform
-		workflowFiles, err := GenerateWorkflows(config, platformData)
-		if err != nil {
-			return models.ProcessFinishedMsg{
-				Success: false,
-				Result:  "",
-				Err:     err,
+		return models.ProcessFinishedMsg{
+			Success: true,
+			Result:  FormatResult(result),
+			Err:     nil,
+		}
+	}
+}
+
+// RunProject sets up the project, generates its workflows and returns what
+// was configured.
+// It is shared by the TUI and the non-interactive init command
+func RunProject(config models.ProjectConfig, platformData models.PlatformData, opts models.GenerateOptions) (models.Result, error) {
+	// Setup project
+	config, err := SetupProject(config)
+	if err != nil {
+		return models.Result{}, err
+	}
+
+	// Keep the workflow being replaced to report the secrets it no longer needs
+	deployPath := filepath.Join(workflowsDir, templateVars(config, false)["workflow_file"])
+	previous, err := os.ReadFile(filepath.Join(opts.ProjectPath, deployPath))
+	if err != nil && !os.IsNotExist(err) {
+		return models.Result{}, fmt.Errorf("failed to read %s: %w", deployPath, err)
+	}
+
+	// Generate workflows based on platform
+	workflowFiles, projectId, err := GenerateWorkflows(config, platformData, opts)
+	if err != nil {
+		return models.Result{}, err
+	}
+
+	result := models.Result{
+		Project:           config,
+		PlatformProjectId: projectId,
+		DryRun:            opts.DryRun,
+		Files:             []models.FileResult{},
+		SecretsRequired:   []string{},
+		Warnings:          []string{},
+	}
+
+	notifyRunner := ""
+	for _, file := range workflowFiles {
+		if meta, ok := ParseWorkflowHeader(file.Content); ok && meta.Kind == "notification" {
+			notifyRunner = meta.Runner
+		}
+
+		result.Files = append(result.Files, models.FileResult{Path: file.Path, Status: file.Status})
+		if file.Backup != "" {
+			result.Backup = file.Backup
+		}
+
+		for _, secret := range WorkflowSecrets(file.Content) {
+			if !slices.Contains(result.SecretsRequired, secret) {
+				result.SecretsRequired = append(result.SecretsRequired, secret)
 			}
 		}
+	}
+	slices.Sort(result.SecretsRequired)
 
-		// Build success message
-		resultBuilder.WriteString(fmt.Sprintf("Project: %s\n", config.Name))
-		resultBuilder.WriteString(fmt.Sprintf("Deploy Branch: %s\n", config.DeployBranch))
-		resultBuilder.WriteString(fmt.Sprintf("Build Folder: %s\n", config.BuildFolder))
-		resultBuilder.WriteString(fmt.Sprintf("Platform: %s\n", config.Platform))
-		resultBuilder.WriteString("\nGenerated workflow files:\n")
+	unused, err := replacedSecrets(opts.ProjectPath, deployPath, string(previous), result.SecretsRequired)
+	if err != nil {
+		return models.Result{}, err
+	}
+	for _, secret := range unused {
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("secret %s is no longer used by any workflow, it can be deleted from the GitHub repository", secret))
+	}
 
-		for _, file := range workflowFiles {
-			resultBuilder.WriteString(fmt.Sprintf("- %s\n", file))
+	// In dry-run mode report what would change instead of saving the setup
+	if opts.DryRun {
+		result.Diff, err = DiffWorkflows(opts.ProjectPath, workflowFiles)
+		if err != nil {
+			return models.Result{}, err
 		}
 
-		resultBuilder.WriteString("\nCI/CD pipeline configured successfully!")
+		return result, nil
+	}
+
+	// Persist the setup so later runs can reuse it as defaults
+	result.ConfigPath, err = saveProjectConfig(opts.ProjectPath, config, platformData, notifyRunner)
+	if err != nil {
+		return models.Result{}, err
+	}
 
-		return models.ProcessFinishedMsg{
-			Success: true,
-			Result:  resultBuilder.String(),
-			Err:     nil,
+	return result, nil
+}
+
+// FormatResult builds a human readable summary of a project setup
+func FormatResult(result models.Result) string {
+	// Initialize result builder
+	var resultBuilder strings.Builder
+
+	// In dry-run mode show what would change instead of what was written
+	if result.DryRun {
+		resultBuilder.WriteString("Dry run: no files were written and no platform project was created.\n\n")
+		if result.Diff == "" {
+			resultBuilder.WriteString("Workflow files are up to date.")
+		} else {
+			resultBuilder.WriteString(result.Diff)
+		}
+
+		for _, warning := range result.Warnings {
+			resultBuilder.WriteString(fmt.Sprintf("\nWarning: %s\n", warning))
+		}
+
+		return resultBuilder.String()
+	}
+
+	// Build success message
+	resultBuilder.WriteString(fmt.Sprintf("Project: %s\n", result.Project.Name))
+	resultBuilder.WriteString(fmt.Sprintf("Deploy Branch: %s\n", result.Project.DeployBranch))
+	resultBuilder.WriteString(fmt.Sprintf("Build Folder: %s\n", result.Project.BuildFolder))
+	resultBuilder.WriteString(fmt.Sprintf("Platform: %s\n", result.Project.Platform))
+	if result.PlatformProjectId != "" {
+		resultBuilder.WriteString(fmt.Sprintf("Platform Project ID: %s\n", result.PlatformProjectId))
+	}
+	resultBuilder.WriteString("\nGenerated workflow files:\n")
+
+	for _, file := range result.Files {
+		resultBuilder.WriteString(fmt.Sprintf("- %s (%s)\n", file.Path, file.Status))
+	}
+
+	if len(result.SecretsRequired) > 0 {
+		resultBuilder.WriteString("\nRequired GitHub secrets:\n")
+		for _, secret := range result.SecretsRequired {
+			resultBuilder.WriteString(fmt.Sprintf("- %s\n", secret))
+		}
+	}
+
+	if result.Backup != "" {
+		resultBuilder.WriteString(fmt.Sprintf("\nReplaced files were backed up to %s, run `slark restore %s` to bring them back.\n",
+			backupPath(result.Backup), result.Backup))
+	}
+
+	for _, warning := range result.Warnings {
+		resultBuilder.WriteString(fmt.Sprintf("\nWarning: %s\n", warning))
+	}
+
+	resultBuilder.WriteString(fmt.Sprintf("\nSettings saved to %s\n", result.ConfigPath))
+	resultBuilder.WriteString("\nCI/CD pipeline configured successfully!")
+
+	return resultBuilder.String()
+}
+
+// replacedSecrets returns the secrets of the previous content of the deploy
+// workflow that neither the generated workflows, given by their secrets, nor
+// the other workflows of the project use
+func replacedSecrets(projectPath, deployPath, previous string, required []string) ([]string, error) {
+	if previous == "" {
+		return nil, nil
+	}
+
+	paths, err := findWorkflowFiles(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	stillUsed := slices.Clone(required)
+	for _, path := range paths {
+		if path == deployPath {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(projectPath, path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		stillUsed = append(stillUsed, WorkflowSecrets(string(content))...)
+	}
+
+	var unused []string
+	for _, secret := range WorkflowSecrets(previous) {
+		if !slices.Contains(stillUsed, secret) && !slices.Contains(unused, secret) {
+			unused = append(unused, secret)
 		}
 	}
+
+	slices.Sort(unused)
+	return unused, nil
+}
+
+// saveProjectConfig records the project setup in the repository config file,
+// along with the runner of the notification workflow the first time one is
+// generated, and returns the path it was written to
+func saveProjectConfig(projectPath string, project models.ProjectConfig, platformData models.PlatformData, notifyRunner string) (string, error) {
+	cfg, err := config.Load(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	cfg.Upsert(config.NewProject(project, platformData))
+	if cfg.NotifyRunner == "" {
+		cfg.NotifyRunner = notifyRunner
+	}
+
+	if err := config.Save(projectPath, cfg); err != nil {
+		return "", err
+	}
+
+	return config.FileName, nil
 }
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"slark/internal/testutil"
+)
+
+func TestDiscoverApps(t *testing.T) {
+	projectPath := t.TempDir()
+	testutil.WriteFiles(t, projectPath, map[string]string{
+		"apps/web/package.json":     "{}",
+		"apps/docs/package.json":    "{}",
+		"packages/web/package.json": "{}",
+		"packages/ui/package.json":  "{}",
+	})
+
+	tests := []struct {
+		pattern string
+		want    []string
+		err     string
+	}{
+		{"apps/*", []string{"apps/docs", "apps/web"}, ""},
+		{"packages/*", []string{"packages/ui", "packages/web"}, ""},
+		{"*/*", nil, "apps/web and packages/web would both be named web"},
+		{"*/web", nil, "apps/web and packages/web would both be named web"},
+		{"services/*", nil, "no folder matching services/* holds a package.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := DiscoverApps(projectPath, tt.pattern)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("DiscoverApps error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DiscoverApps: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("DiscoverApps(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"slark/internal/config"
+	"slark/internal/models"
+	"slark/internal/platform"
+)
+
+// notificationWorkflow is the shared workflow deploy workflows call to notify
+const notificationWorkflow = ".telegram-noti.yml"
+
+// RemovePipeline tears down the pipeline of a project and branch. It deletes
+// the platform project when requested, the workflow file, the shared
+// notification workflow once no other workflow uses it, and the config entry.
+// The platform project is kept while a workflow of another branch still
+// deploys to it.
+// The API key is taken from apiKeys, by platform, once the workflow header
+// tells which platform the project lives on.
+// In dry-run mode it only reports what would be removed.
+func RemovePipeline(name, branch string, platformData models.PlatformData, apiKeys map[string]string, deleteProject bool, opts models.GenerateOptions) (models.RemoveResult, error) {
+	var result models.RemoveResult
+
+	paths, err := findWorkflowFiles(opts.ProjectPath)
+	if err != nil {
+		return result, err
+	}
+
+	// Split the workflows into the ones being removed and the ones staying
+	contents := make(map[string]string)
+	var removed, remaining []string
+	var deploys []models.WorkflowMeta
+	for _, path := range paths {
+		content, err := os.ReadFile(filepath.Join(opts.ProjectPath, path))
+		if err != nil {
+			return result, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		contents[path] = string(content)
+
+		meta, ok := ParseWorkflowHeader(string(content))
+		if ok && meta.Kind == "deploy" && meta.Name == name && meta.DeployBranch == branch {
+			result.Platform = meta.Platform
+			removed = append(removed, path)
+		} else {
+			remaining = append(remaining, path)
+			if ok && meta.Kind == "deploy" {
+				deploys = append(deploys, meta)
+			}
+		}
+	}
+
+	if len(removed) == 0 {
+		return result, fmt.Errorf("no slark workflow found for project %s on branch %s", name, branch)
+	}
+
+	// Drop the shared notification workflow when nothing else calls it
+	notifyPath := filepath.Join(workflowsDir, notificationWorkflow)
+	if slices.Contains(remaining, notifyPath) {
+		used := false
+		for _, path := range remaining {
+			if path != notifyPath && strings.Contains(contents[path], notificationWorkflow) {
+				used = true
+			}
+		}
+
+		if !used {
+			removed = append(removed, notifyPath)
+			remaining = slices.DeleteFunc(remaining, func(path string) bool { return path == notifyPath })
+		}
+	}
+
+	result.RemovedFiles = removed
+	result.UnusedSecrets = unusedSecrets(removed, remaining, contents)
+
+	// Other branches may deploy to the same platform project
+	if deleteProject {
+		var branches []string
+		for _, meta := range deploys {
+			if meta.Name == name && meta.Platform == result.Platform {
+				branches = append(branches, meta.DeployBranch)
+			}
+		}
+
+		if len(branches) > 0 {
+			deleteProject = false
+			result.Warnings = append(result.Warnings, fmt.Sprintf("the %s project %s was kept, the workflows of the branches %s still deploy to it",
+				result.Platform, name, strings.Join(branches, ", ")))
+		}
+	}
+
+	if opts.DryRun {
+		result.ProjectDeleted = deleteProject
+		return result, nil
+	}
+
+	// Delete the platform project first so a failure leaves the pipeline intact
+	if deleteProject {
+		platformData.ApiKey = apiKeys[result.Platform]
+		if err := deletePlatformProject(result.Platform, name, platformData); err != nil {
+			return result, err
+		}
+		result.ProjectDeleted = true
+	}
+
+	// Keep the removed files so they can be restored
+	result.Backup = newBackupId(opts.ProjectPath)
+	for _, path := range removed {
+		if err := backupFile(opts.ProjectPath, result.Backup, path); err != nil {
+			return result, err
+		}
+	}
+
+	for _, path := range removed {
+		if err := os.Remove(filepath.Join(opts.ProjectPath, path)); err != nil {
+			return result, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+
+		// The merge base may be missing for files generated by older versions
+		if err := os.Remove(basePath(opts.ProjectPath, path)); err != nil && !os.IsNotExist(err) {
+			return result, fmt.Errorf("failed to remove merge base of %s: %w", path, err)
+		}
+	}
+
+	cfg, err := config.Load(opts.ProjectPath)
+	if err != nil {
+		return result, err
+	}
+	if cfg.Remove(name, branch) {
+		if err := config.Save(opts.ProjectPath, cfg); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// deletePlatformProject deletes the project on its deployment platform
+func deletePlatformProject(platformName, name string, platformData models.PlatformData) error {
+	switch platformName {
+	case "vercel":
+		if platformData.ApiKey == "" {
+			return fmt.Errorf("Vercel API key is required to delete the project")
+		}
+		if err := platform.DeleteVercelProject(name, platformData); err != nil {
+			return fmt.Errorf("failed to delete Vercel project: %w", err)
+		}
+
+	case "cloudflare":
+		if platformData.ApiKey == "" {
+			return fmt.Errorf("cloudflare API key is required to delete the project")
+		}
+		if err := platform.DeleteCloudflareProject(name, platformData); err != nil {
+			return fmt.Errorf("failed to delete Cloudflare project: %w", err)
+		}
+
+	default:
+		return fmt.Errorf("unsupported platform: %s", platformName)
+	}
+
+	return nil
+}
+
+// unusedSecrets returns the secrets referenced by the removed workflows that
+// none of the remaining workflows reference
+func unusedSecrets(removed, remaining []string, contents map[string]string) []string {
+	stillUsed := make(map[string]bool)
+	for _, path := range remaining {
+		for _, secret := range WorkflowSecrets(contents[path]) {
+			stillUsed[secret] = true
+		}
+	}
+
+	var unused []string
+	for _, path := range removed {
+		for _, secret := range WorkflowSecrets(contents[path]) {
+			if !stillUsed[secret] && !slices.Contains(unused, secret) {
+				unused = append(unused, secret)
+			}
+		}
+	}
+
+	slices.Sort(unused)
+	return unused
+}
+
+// FormatRemoveResult builds a human readable summary of a removed pipeline
+func FormatRemoveResult(name string, result models.RemoveResult, opts models.GenerateOptions) string {
+	var b strings.Builder
+
+	removedVerb, deletedVerb := "Removed", "Deleted"
+	if opts.DryRun {
+		b.WriteString("Dry run: nothing was removed.\n\n")
+		removedVerb, deletedVerb = "Would remove", "Would delete"
+	}
+
+	fmt.Fprintf(&b, "%s pipeline %s (%s)\n", removedVerb, name, result.Platform)
+	for _, path := range result.RemovedFiles {
+		fmt.Fprintf(&b, "- %s\n", path)
+	}
+
+	if result.ProjectDeleted {
+		fmt.Fprintf(&b, "\n%s the %s project %s\n", deletedVerb, result.Platform, name)
+	}
+
+	if result.Backup != "" {
+		fmt.Fprintf(&b, "\nThe removed files were backed up to %s, run `slark restore %s` to bring them back.\n",
+			backupPath(result.Backup), result.Backup)
+	}
+
+	if len(result.UnusedSecrets) > 0 {
+		b.WriteString("\nThese GitHub secrets are no longer used by any workflow and can be deleted:\n")
+		for _, secret := range result.UnusedSecrets {
+			fmt.Fprintf(&b, "- %s\n", secret)
+		}
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(&b, "\nWarning: %s\n", warning)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"slark/internal/config"
+	"slark/internal/models"
+	"slark/internal/template"
+)
+
+// writePipeline sets up the pipeline of a project the way init does,
+// without creating the platform project
+func writePipeline(t *testing.T, projectPath string, project models.ProjectConfig, notify bool) {
+	t.Helper()
+
+	project, err := SetupProject(project)
+	if err != nil {
+		t.Fatalf("SetupProject: %v", err)
+	}
+
+	lib := template.NewLibrary(projectPath)
+	deploy, err := generateDeployWorkflow(lib, projectPath, project, notify)
+	if err != nil {
+		t.Fatalf("generateDeployWorkflow: %v", err)
+	}
+	files := []models.WorkflowFile{deploy}
+
+	platformData := models.PlatformData{}
+	if notify {
+		notification, err := generateNotificationWorkflow(lib, "")
+		if err != nil {
+			t.Fatalf("generateNotificationWorkflow: %v", err)
+		}
+		files = append(files, notification)
+		platformData.ChatId = "-100"
+	}
+
+	if err := writeWorkflowFiles(projectPath, files); err != nil {
+		t.Fatalf("writeWorkflowFiles: %v", err)
+	}
+	if _, err := saveProjectConfig(projectPath, project, platformData, ""); err != nil {
+		t.Fatalf("saveProjectConfig: %v", err)
+	}
+}
+
+// exists reports whether the file, relative to the project, exists
+func exists(projectPath, path string) bool {
+	_, err := os.Stat(filepath.Join(projectPath, path))
+	return err == nil
+}
+
+func TestRemovePipeline(t *testing.T) {
+	projectPath := t.TempDir()
+	writePipeline(t, projectPath, models.ProjectConfig{Name: "web", DeployBranch: "main", BuildFolder: ".", Platform: "vercel"}, true)
+	writePipeline(t, projectPath, models.ProjectConfig{Name: "docs", DeployBranch: "main", BuildFolder: ".", Platform: "cloudflare"}, true)
+
+	webPath := filepath.Join(workflowsDir, "web.main.yml")
+	docsPath := filepath.Join(workflowsDir, "docs.main.yml")
+	notifyPath := filepath.Join(workflowsDir, notificationWorkflow)
+	opts := models.GenerateOptions{ProjectPath: projectPath}
+
+	// A dry run leaves everything in place
+	result, err := RemovePipeline("web", "main", models.PlatformData{}, nil, false, models.GenerateOptions{ProjectPath: projectPath, DryRun: true})
+	if err != nil {
+		t.Fatalf("RemovePipeline dry run: %v", err)
+	}
+	if !slices.Equal(result.RemovedFiles, []string{webPath}) || !exists(projectPath, webPath) {
+		t.Errorf("dry run removed files = %q", result.RemovedFiles)
+	}
+
+	// The notification workflow stays while docs still calls it
+	result, err = RemovePipeline("web", "main", models.PlatformData{}, nil, false, opts)
+	if err != nil {
+		t.Fatalf("RemovePipeline web: %v", err)
+	}
+	if result.Platform != "vercel" || !slices.Equal(result.RemovedFiles, []string{webPath}) {
+		t.Errorf("removed %s files %q, want vercel files [%q]", result.Platform, result.RemovedFiles, webPath)
+	}
+	if !slices.Contains(result.UnusedSecrets, "VERCEL_TOKEN") || slices.Contains(result.UnusedSecrets, "TELEGRAM_BOT_TOKEN") {
+		t.Errorf("unused secrets = %q", result.UnusedSecrets)
+	}
+	if exists(projectPath, webPath) || !exists(projectPath, docsPath) || !exists(projectPath, notifyPath) {
+		t.Error("only the web workflow should be removed")
+	}
+	if _, err := os.Stat(basePath(projectPath, webPath)); !os.IsNotExist(err) {
+		t.Errorf("merge base of %s was kept", webPath)
+	}
+	if !exists(projectPath, filepath.Join(backupPath(result.Backup), webPath)) {
+		t.Errorf("%s was not backed up in %s", webPath, result.Backup)
+	}
+
+	cfg, err := config.Load(projectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cfg.Lookup("web", "main"); ok || len(cfg.Projects) != 1 {
+		t.Errorf("config projects = %+v, want only docs", cfg.Projects)
+	}
+
+	// The last pipeline takes the notification workflow with it
+	result, err = RemovePipeline("docs", "main", models.PlatformData{}, nil, false, opts)
+	if err != nil {
+		t.Fatalf("RemovePipeline docs: %v", err)
+	}
+	if !slices.Equal(result.RemovedFiles, []string{docsPath, notifyPath}) || exists(projectPath, notifyPath) {
+		t.Errorf("removed files = %q, want the docs and notification workflows", result.RemovedFiles)
+	}
+
+	cfg, err = config.Load(projectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Projects) != 0 {
+		t.Errorf("config projects = %+v, want none", cfg.Projects)
+	}
+
+	if _, err := RemovePipeline("docs", "main", models.PlatformData{}, nil, false, opts); err == nil {
+		t.Error("removing a missing pipeline succeeded")
+	}
+}
+
+func TestRemovePipelineKeepsSharedProject(t *testing.T) {
+	projectPath := t.TempDir()
+	writePipeline(t, projectPath, models.ProjectConfig{Name: "web", DeployBranch: "main", BuildFolder: ".", Platform: "vercel"}, false)
+	writePipeline(t, projectPath, models.ProjectConfig{Name: "web", DeployBranch: "staging", BuildFolder: ".", Platform: "vercel"}, false)
+	opts := models.GenerateOptions{ProjectPath: projectPath}
+
+	// Without an API key deleting the project would fail, so it must be skipped
+	result, err := RemovePipeline("web", "main", models.PlatformData{}, nil, true, opts)
+	if err != nil {
+		t.Fatalf("RemovePipeline: %v", err)
+	}
+	if result.ProjectDeleted {
+		t.Error("the project still used by the staging branch was deleted")
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "staging") {
+		t.Errorf("warnings = %q, want one naming the staging branch", result.Warnings)
+	}
+	if exists(projectPath, filepath.Join(workflowsDir, "web.main.yml")) || !exists(projectPath, filepath.Join(workflowsDir, "web.staging.yml")) {
+		t.Error("only the main workflow should be removed")
+	}
+
+	// The last branch deletes the project, which needs the API key
+	_, err = RemovePipeline("web", "staging", models.PlatformData{}, nil, true, opts)
+	if err == nil || !strings.Contains(err.Error(), "API key") {
+		t.Errorf("RemovePipeline error = %v, want the missing API key", err)
+	}
+	if !exists(projectPath, filepath.Join(workflowsDir, "web.staging.yml")) {
+		t.Error("a failed project deletion removed the workflow")
+	}
+}
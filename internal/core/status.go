@@ -0,0 +1,180 @@
+package core
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"slark/internal/models"
+	"slark/internal/platform"
+
+	"gopkg.in/yaml.v3"
+)
+
+// workflowTriggers is the part of a workflow file status reads
+type workflowTriggers struct {
+	On struct {
+		Push struct {
+			Branches []string `yaml:"branches"`
+			Paths    []string `yaml:"paths"`
+		} `yaml:"push"`
+	} `yaml:"on"`
+	Jobs map[string]struct {
+		Uses string `yaml:"uses"`
+	} `yaml:"jobs"`
+}
+
+// ListPipelines scans the workflow files of the project and returns every
+// slark-generated deploy pipeline
+func ListPipelines(projectPath string) ([]models.Pipeline, error) {
+	paths, err := findWorkflowFiles(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var pipelines []models.Pipeline
+	for _, workflowPath := range paths {
+		content, err := os.ReadFile(filepath.Join(projectPath, workflowPath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", workflowPath, err)
+		}
+
+		meta, ok := ParseWorkflowHeader(string(content))
+		if !ok || meta.Kind != "deploy" {
+			continue
+		}
+
+		pipeline, err := describePipeline(projectPath, workflowPath, meta, string(content))
+		if err != nil {
+			return nil, err
+		}
+		pipelines = append(pipelines, pipeline)
+	}
+
+	return pipelines, nil
+}
+
+// describePipeline reads the triggers, notification wiring and secrets of a
+// deploy workflow
+func describePipeline(projectPath, workflowPath string, meta models.WorkflowMeta, content string) (models.Pipeline, error) {
+	pipeline := models.Pipeline{
+		Path:          workflowPath,
+		Name:          meta.Name,
+		Platform:      meta.Platform,
+		DeployBranch:  meta.DeployBranch,
+		BuildFolder:   meta.BuildFolder,
+		Template:      meta.Template,
+		Vars:          meta.Vars,
+		Previews:      meta.Preview,
+		Verify:        meta.Verify,
+		Runner:        meta.Runner,
+		Notifications: "none",
+		Secrets:       WorkflowSecrets(content),
+	}
+
+	var triggers workflowTriggers
+	if err := yaml.Unmarshal([]byte(content), &triggers); err != nil {
+		return pipeline, fmt.Errorf("failed to parse %s: %w", workflowPath, err)
+	}
+	pipeline.PathFilters = triggers.On.Push.Paths
+
+	// Follow calls to local reusable workflows such as the notification workflow
+	for _, job := range triggers.Jobs {
+		if !strings.HasPrefix(job.Uses, "./") {
+			continue
+		}
+
+		called := strings.TrimPrefix(job.Uses, "./")
+		isNotification := path.Base(called) == notificationWorkflow
+
+		calledContent, err := os.ReadFile(filepath.Join(projectPath, called))
+		if err != nil {
+			if isNotification {
+				pipeline.Notifications = fmt.Sprintf("broken, %s is missing", called)
+			}
+			continue
+		}
+		if isNotification {
+			pipeline.Notifications = "telegram"
+		}
+
+		for _, secret := range WorkflowSecrets(string(calledContent)) {
+			if !slices.Contains(pipeline.Secrets, secret) {
+				pipeline.Secrets = append(pipeline.Secrets, secret)
+			}
+		}
+	}
+	slices.Sort(pipeline.Secrets)
+
+	return pipeline, nil
+}
+
+// AddLiveState looks up the state of each pipeline's project on its platform
+func AddLiveState(pipelines []models.Pipeline, platformData models.PlatformData) {
+	for i, pipeline := range pipelines {
+		if pipeline.Platform != "vercel" {
+			pipelines[i].Live = "not available for " + pipeline.Platform
+			continue
+		}
+
+		project, err := platform.GetVercelProject(pipeline.Name, platformData)
+		if err != nil {
+			pipelines[i].Live = err.Error()
+			continue
+		}
+
+		live := fmt.Sprintf("project %s", project.Id)
+		if len(project.LatestDeployments) > 0 {
+			deployment := project.LatestDeployments[0]
+			live += fmt.Sprintf(", latest deployment %s at https://%s", deployment.ReadyState, deployment.URL)
+		}
+		pipelines[i].Live = live
+	}
+}
+
+// FormatPipelines builds a human readable listing of pipelines
+func FormatPipelines(pipelines []models.Pipeline) string {
+	if len(pipelines) == 0 {
+		return "No slark pipelines configured. Run `slark init` to set one up.\n"
+	}
+
+	var b strings.Builder
+	for i, pipeline := range pipelines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		fmt.Fprintf(&b, "%s\n", pipeline.Path)
+		fmt.Fprintf(&b, "  project:       %s\n", pipeline.Name)
+		fmt.Fprintf(&b, "  platform:      %s\n", pipeline.Platform)
+		fmt.Fprintf(&b, "  deploy branch: %s\n", pipeline.DeployBranch)
+		fmt.Fprintf(&b, "  build folder:  %s\n", pipeline.BuildFolder)
+		fmt.Fprintf(&b, "  template:      %s/%s\n", pipeline.Platform, pipeline.Template)
+		if len(pipeline.Vars) > 0 {
+			var vars []string
+			for _, name := range slices.Sorted(maps.Keys(pipeline.Vars)) {
+				vars = append(vars, name+"="+pipeline.Vars[name])
+			}
+			fmt.Fprintf(&b, "  variables:     %s\n", strings.Join(vars, ", "))
+		}
+		fmt.Fprintf(&b, "  path filters:  %s\n", strings.Join(pipeline.PathFilters, ", "))
+		runner := pipeline.Runner
+		if runner == "" {
+			runner = "template default"
+		}
+		fmt.Fprintf(&b, "  runner:        %s\n", runner)
+		fmt.Fprintf(&b, "  previews:      %t\n", pipeline.Previews)
+		fmt.Fprintf(&b, "  verify:        %t\n", pipeline.Verify)
+		fmt.Fprintf(&b, "  notifications: %s\n", pipeline.Notifications)
+		fmt.Fprintf(&b, "  secrets:       %s\n", strings.Join(pipeline.Secrets, ", "))
+		if pipeline.Live != "" {
+			fmt.Fprintf(&b, "  live:          %s\n", pipeline.Live)
+		}
+	}
+
+	return b.String()
+}
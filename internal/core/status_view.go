@@ -0,0 +1,94 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"slark/internal/models"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// StatusModel is the TUI screen listing the configured pipelines
+type StatusModel struct {
+	Pipelines []models.Pipeline
+	Table     table.Model
+}
+
+// NewStatusModel creates the status screen for the given pipelines
+func NewStatusModel(pipelines []models.Pipeline) StatusModel {
+	columns := []table.Column{
+		{Title: "Project", Width: 20},
+		{Title: "Platform", Width: 10},
+		{Title: "Branch", Width: 14},
+		{Title: "Build Folder", Width: 20},
+		{Title: "Notifications", Width: 14},
+	}
+
+	var rows []table.Row
+	for _, pipeline := range pipelines {
+		rows = append(rows, table.Row{
+			pipeline.Name,
+			pipeline.Platform,
+			pipeline.DeployBranch,
+			pipeline.BuildFolder,
+			pipeline.Notifications,
+		})
+	}
+
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(min(max(len(rows), 1), 10)+1),
+	)
+
+	return StatusModel{Pipelines: pipelines, Table: t}
+}
+
+func (m StatusModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m StatusModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c", "esc", "q":
+			return m, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+	m.Table, cmd = m.Table.Update(msg)
+	return m, cmd
+}
+
+func (m StatusModel) View() string {
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render("Slark pipelines"))
+	b.WriteString("\n\n")
+
+	if len(m.Pipelines) == 0 {
+		b.WriteString("No slark pipelines configured. Run `slark init` to set one up.\n\n")
+		b.WriteString(helpStyle.Render("Press q to exit"))
+		return b.String()
+	}
+
+	b.WriteString(m.Table.View())
+	b.WriteString("\n\n")
+
+	// Show the details that don't fit in the table for the selected pipeline
+	pipeline := m.Pipelines[m.Table.Cursor()]
+	fmt.Fprintf(&b, "Workflow:     %s\n", pipeline.Path)
+	fmt.Fprintf(&b, "Path filters: %s\n", strings.Join(pipeline.PathFilters, ", "))
+	fmt.Fprintf(&b, "Secrets:      %s\n", strings.Join(pipeline.Secrets, ", "))
+	if pipeline.Live != "" {
+		fmt.Fprintf(&b, "Live:         %s\n", pipeline.Live)
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("↑/↓ select • q exit"))
+	return b.String()
+}
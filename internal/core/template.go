@@ -3,79 +3,318 @@ package core
 import (
 	"fmt"
 	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+
+	"slark/internal/models"
+	"slark/internal/template"
 )
 
-// ListTemplates prints all available templates categorized by platform.
-func ListTemplates() {
-	// Path to templates directory
-	templatesPath := "templates"
+// ListTemplates returns the templates available to the project categorized
+// by platform, with project and user templates overriding built-in ones
+func ListTemplates(projectPath string) ([]models.TemplateInfo, error) {
+	list, err := template.NewLibrary(projectPath).List()
+	if err != nil {
+		return nil, err
+	}
 
-	// Check if templates directory exists
-	if _, err := os.Stat(templatesPath); os.IsNotExist(err) {
-		fmt.Println("No templates found.")
-		return
+	var templates []models.TemplateInfo
+	for _, tmpl := range list {
+		templates = append(templates, templateInfo(tmpl))
 	}
 
-	// Map to store templates by category
-	templatesByCategory := make(map[string][]string)
+	// Keep each category together, the library sorts by name within it
+	slices.SortStableFunc(templates, func(a, b models.TemplateInfo) int {
+		return strings.Compare(a.Category, b.Category)
+	})
 
-	// Walk through template directories
-	err := filepath.WalkDir(templatesPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
+	return templates, nil
+}
 
-		// Skip the root templates directory
-		if path == templatesPath {
-			return nil
-		}
+// TemplateVariants returns the templates a platform's workflow can be
+// rendered from, such as vercel/basic and vercel/advanced
+func TemplateVariants(projectPath, platform string) ([]models.TemplateInfo, error) {
+	templates, err := ListTemplates(projectPath)
+	if err != nil {
+		return nil, err
+	}
 
-		// Get relative path
-		relPath, err := filepath.Rel(templatesPath, path)
-		if err != nil {
-			return err
+	var variants []models.TemplateInfo
+	for _, tmpl := range templates {
+		variant, found := strings.CutPrefix(tmpl.Path, platform+"/")
+		if found && !strings.Contains(variant, "/") {
+			variants = append(variants, tmpl)
 		}
+	}
+
+	return variants, nil
+}
 
-		// Split the path to get category
-		parts := strings.Split(relPath, string(os.PathSeparator))
-		category := strings.Title(parts[0])
+// templateInfo describes a library template for listing and showing
+func templateInfo(tmpl template.Template) models.TemplateInfo {
+	category, base, found := strings.Cut(tmpl.Name, "/")
+	if !found {
+		category, base = "other", category
+	}
+
+	// Make the template name readable
+	templateName := strings.ReplaceAll(base, "-", " ")
+	templateName = strings.ReplaceAll(templateName, "_", " ")
+
+	return models.TemplateInfo{
+		Category:     strings.Title(category),
+		Name:         strings.Title(templateName),
+		Path:         tmpl.Name,
+		Source:       tmpl.Source,
+		File:         tmpl.File,
+		TemplateMeta: tmpl.Meta,
+	}
+}
+
+// FormatTemplates renders the template list grouped by category, with the
+// description of each template
+func FormatTemplates(templates []models.TemplateInfo) string {
+	if len(templates) == 0 {
+		return "No templates found.\n"
+	}
+
+	// Align the descriptions
+	width := 0
+	for _, tmpl := range templates {
+		width = max(width, len(tmpl.Path))
+	}
 
-		// Skip directories
-		if d.IsDir() {
-			return nil
+	var b strings.Builder
+	b.WriteString("Available templates:\n")
+
+	category := ""
+	for _, tmpl := range templates {
+		if tmpl.Category != category {
+			category = tmpl.Category
+			fmt.Fprintf(&b, "\n%s:\n", category)
 		}
 
-		// Skip hidden files
-		if strings.HasPrefix(filepath.Base(path), ".") {
-			return nil
+		description := tmpl.Description
+		if tmpl.Source != template.SourceBuiltin {
+			description = strings.TrimSpace(description + " (" + tmpl.Source + ")")
 		}
+		fmt.Fprintf(&b, "  %-*s  %s\n", width, tmpl.Path, description)
+	}
+
+	return b.String()
+}
 
-		// Get template name without extension
-		templateName := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
-		templateName = strings.ReplaceAll(templateName, "-", " ")
-		templateName = strings.ReplaceAll(templateName, "_", " ")
-		templateName = strings.Title(templateName)
+// ShowTemplate returns a template available to the project along with the
+// layer it came from. The name is the template path as shown by
+// ListTemplates, the extension is optional.
+func ShowTemplate(projectPath, name string) (models.TemplateInfo, string, error) {
+	lib := template.NewLibrary(projectPath)
 
-		// Add to map
-		templatesByCategory[category] = append(templatesByCategory[category], templateName)
+	tmpl, err := lib.Load(name)
+	if err != nil {
+		return models.TemplateInfo{}, "", err
+	}
 
-		return nil
-	})
+	// Report the variables of the layouts and partials the template uses too,
+	// and the frameworks left by the layouts it extends
+	info := templateInfo(tmpl)
+	info.Variables, err = lib.Variables(tmpl.Name)
+	if err != nil {
+		return models.TemplateInfo{}, "", err
+	}
+	info.Frameworks, err = lib.Frameworks(tmpl.Name)
+	if err != nil {
+		return models.TemplateInfo{}, "", err
+	}
+
+	return info, tmpl.Content, nil
+}
+
+// FormatTemplateSource describes where a template was loaded from
+func FormatTemplateSource(info models.TemplateInfo) string {
+	if info.File == "" {
+		return fmt.Sprintf("%s: %s template", info.Path, info.Source)
+	}
+	return fmt.Sprintf("%s: %s template from %s", info.Path, info.Source, info.File)
+}
+
+// sampleProject holds the project settings templates are rendered with by
+// `slark templates render`
+var sampleProject = models.ProjectConfig{
+	Name:         "my-app",
+	DeployBranch: "main",
+	BuildFolder:  ".",
+}
+
+// NewTemplate scaffolds a custom template from an existing one, in the user
+// template directory or, with inProject, in the project. It writes the
+// template and a sample values file and returns their paths.
+func NewTemplate(projectPath, name, from string, inProject bool) (string, string, error) {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".yml"), ".yaml")
+	if !fs.ValidPath(name) || name == "." || strings.HasSuffix(name+".yaml", template.ValuesSuffix) {
+		return "", "", fmt.Errorf("invalid template name: %s", name)
+	}
+
+	// init selects templates by variant within the directory of the platform
+	platform, variant, _ := strings.Cut(name, "/")
+	if (platform != "vercel" && platform != "cloudflare") || variant == "" || strings.Contains(variant, "/") {
+		return "", "", fmt.Errorf("invalid template name %s, expected vercel/<variant> or cloudflare/<variant>", name)
+	}
+
+	lib := template.NewLibrary(projectPath)
+
+	source, err := lib.Load(from)
+	if err != nil {
+		return "", "", err
+	}
+
+	variables, err := lib.Variables(source.Name)
+	if err != nil {
+		return "", "", err
+	}
+
+	blocks, err := lib.Blocks(source.Name)
+	if err != nil {
+		return "", "", err
+	}
+
+	// The new template extends the same templates, list what they support
+	source.Meta.Frameworks, err = lib.Frameworks(source.Name)
+	if err != nil {
+		return "", "", err
+	}
+
+	dir := template.UserDir()
+	if inProject {
+		dir = template.ProjectDir(projectPath)
+	}
+	if dir == "" {
+		return "", "", fmt.Errorf("cannot find the user config directory, use --project to create the template in the project")
+	}
+
+	templatePath := filepath.Join(dir, filepath.FromSlash(name+".yml"))
+	valuesPath := filepath.Join(dir, filepath.FromSlash(name+template.ValuesSuffix))
 
+	// Never overwrite a template someone is working on
+	for _, path := range []string{templatePath, valuesPath} {
+		if _, err := os.Stat(path); err == nil {
+			return "", "", fmt.Errorf("%s already exists", path)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(templatePath), 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create template directory: %w", err)
+	}
+
+	content := scaffoldFrontMatter(name, source, variables, blocks) + source.Body
+	if err := os.WriteFile(templatePath, []byte(content), 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write %s: %w", templatePath, err)
+	}
+
+	// Detected values are only shown, setting them would turn detection off
+	detected, err := analyzedValues(projectPath, sampleProject)
 	if err != nil {
-		fmt.Printf("Error reading templates: %v\n", err)
-		return
+		return "", "", err
+	}
+
+	if err := os.WriteFile(valuesPath, []byte(scaffoldValues(name, variables, detected)), 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write %s: %w", valuesPath, err)
+	}
+
+	return templatePath, valuesPath, nil
+}
+
+// scaffoldFrontMatter writes the front-matter of a new template, documenting
+// the tags, blocks and every variable it can use
+func scaffoldFrontMatter(name string, source template.Template, variables []models.TemplateVariable, blocks []string) string {
+	computed := slices.Sorted(maps.Keys(templateVars(sampleProject, false)))
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "# Created from %s by `slark templates new`.\n", source.Name)
+	fmt.Fprintf(&b, "# Preview it with `slark templates render %s --values <file>`.\n", name)
+	b.WriteString("#\n")
+	b.WriteString("# Tags: {{variable}}, {{> partial}} for partials/<partial>,\n")
+	b.WriteString("# {{#if variable}}...{{else}}...{{/if}}, {{extends template}} and\n")
+	b.WriteString("# {{#block name}}...{{/block}} to replace a block of the extended template.\n")
+	if len(blocks) > 0 {
+		fmt.Fprintf(&b, "# Blocks you can replace: %s\n", strings.Join(blocks, ", "))
+	}
+	fmt.Fprintf(&b, "# Set by slark from the project settings: %s\n", strings.Join(computed, ", "))
+	b.WriteString("# Other variables are set with --set or --values and must be declared below.\n")
+
+	description := source.Meta.Description
+	if description == "" {
+		description = "Custom workflow based on " + source.Name
+	}
+	fmt.Fprintf(&b, "description: %s\n", strconv.Quote(description))
+	if source.Meta.Platform != "" {
+		fmt.Fprintf(&b, "platform: %s\n", source.Meta.Platform)
+	}
+	if len(source.Meta.Frameworks) > 0 {
+		fmt.Fprintf(&b, "frameworks: [%s]\n", strings.Join(source.Meta.Frameworks, ", "))
+	}
+
+	if len(variables) > 0 {
+		b.WriteString("variables:\n")
+	}
+	for _, variable := range variables {
+		fmt.Fprintf(&b, "  - name: %s\n", variable.Name)
+		if variable.Description != "" {
+			fmt.Fprintf(&b, "    description: %s\n", strconv.Quote(variable.Description))
+		}
+		if variable.Required {
+			b.WriteString("    required: true\n")
+		}
+		if variable.Default != "" {
+			fmt.Fprintf(&b, "    default: %s\n", strconv.Quote(variable.Default))
+		}
 	}
 
-	// Print templates by category
-	fmt.Println("Available templates:")
-	for category, templates := range templatesByCategory {
-		fmt.Printf("\n%s:\n", category)
-		for _, tmpl := range templates {
-			fmt.Printf("  - %s\n", tmpl)
+	b.WriteString("---\n")
+	return b.String()
+}
+
+// scaffoldValues writes a sample values file with the variables a user can
+// set. Variables detected from the project are commented out with the value
+// detected for it, so passing the file does not override the detection.
+func scaffoldValues(name string, variables []models.TemplateVariable, detected map[string]string) string {
+	computed := templateVars(sampleProject, false)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Sample values for %s, use them with --values.\n", name)
+	for _, variable := range variables {
+		if _, ok := computed[variable.Name]; ok {
+			continue
+		}
+
+		b.WriteString("\n")
+		if variable.Description != "" {
+			fmt.Fprintf(&b, "# %s\n", variable.Description)
+		}
+
+		value, isDetected := detected[variable.Name]
+		if !isDetected || variable.Required {
+			fmt.Fprintf(&b, "%s: %s\n", variable.Name, strconv.Quote(variable.Default))
+			continue
+		}
+
+		if value == "" {
+			value = variable.Default
 		}
+		b.WriteString("# Detected from the project, uncomment to override\n")
+		fmt.Fprintf(&b, "# %s: %s\n", variable.Name, strconv.Quote(value))
 	}
+
+	return b.String()
+}
+
+// RenderTemplate renders a template available to the project with sample
+// project settings and the given values
+func RenderTemplate(projectPath, name string, values map[string]string) (string, error) {
+	return template.NewLibrary(projectPath).RenderFile(name, templateVars(sampleProject, true), values)
 }
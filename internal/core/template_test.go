@@ -0,0 +1,66 @@
+package core
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"slark/internal/testutil"
+)
+
+func TestNewTemplateNames(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"vercel/custom", true},
+		{"cloudflare/custom.yml", true},
+		{"custom", false},
+		{"layouts/custom", false},
+		{"vercel/", false},
+		{"vercel/nested/custom", false},
+		{"../vercel/custom", false},
+		{"vercel/custom.values", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := NewTemplate(t.TempDir(), tt.name, "vercel/basic", true)
+			if (err == nil) != tt.ok {
+				t.Errorf("NewTemplate(%q) error = %v, want success %t", tt.name, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNewTemplateValuesKeepDetection(t *testing.T) {
+	projectPath := t.TempDir()
+	testutil.WriteFiles(t, projectPath, map[string]string{
+		"package.json":   `{"scripts": {"build": "vite build"}}`,
+		"pnpm-lock.yaml": "lockfileVersion: '9.0'\n",
+	})
+
+	_, valuesPath, err := NewTemplate(projectPath, "cloudflare/custom", "cloudflare/advanced", true)
+	if err != nil {
+		t.Fatalf("NewTemplate: %v", err)
+	}
+	data, err := os.ReadFile(valuesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := string(data)
+
+	for _, line := range strings.Split(values, "\n") {
+		for _, name := range []string{"install_command", "node_version", "setup_pnpm", "node_cache"} {
+			if strings.HasPrefix(line, name+":") {
+				t.Errorf("detected variable is set: %s", line)
+			}
+		}
+	}
+	if !strings.Contains(values, `# install_command: "pnpm install --frozen-lockfile"`) {
+		t.Errorf("values do not show the detected install command:\n%s", values)
+	}
+	if !strings.Contains(values, "\nruns_on: ") {
+		t.Errorf("values do not set runs_on:\n%s", values)
+	}
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"slark/internal/models"
+	"slark/internal/template"
+	"slark/internal/utils"
+)
+
+// workflowsDir is where GitHub Actions looks for workflow files
+const workflowsDir = ".github/workflows"
+
+// UpdateWorkflows regenerates every slark-generated workflow with the current
+// templates and three-way merges the output with local edits, using the
+// previously generated version as the common base. Files with conflicting
+// edits are reported and left untouched, updated files are backed up first.
+func UpdateWorkflows(opts models.GenerateOptions) ([]models.UpdatedFile, error) {
+	paths, err := findWorkflowFiles(opts.ProjectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	lib := template.NewLibrary(opts.ProjectPath)
+
+	// Files updated in one run share a backup
+	backupId := ""
+
+	var results []models.UpdatedFile
+	for _, path := range paths {
+		current, err := os.ReadFile(filepath.Join(opts.ProjectPath, path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		// Leave hand-written workflows alone
+		meta, ok := ParseWorkflowHeader(string(current))
+		if !ok {
+			continue
+		}
+
+		file, err := regenerateWorkflow(lib, opts.ProjectPath, meta)
+		if err != nil {
+			return nil, fmt.Errorf("failed to regenerate %s: %w", path, err)
+		}
+		file.Path = path
+
+		result, err := mergeWorkflow(file, string(current), &backupId, opts)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// findWorkflowFiles lists the workflow files in the project, including hidden ones.
+// The returned paths are relative to the project path.
+func findWorkflowFiles(projectPath string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(projectPath, workflowsDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", workflowsDir, err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		paths = append(paths, filepath.Join(workflowsDir, entry.Name()))
+	}
+
+	return paths, nil
+}
+
+// regenerateWorkflow renders a workflow again from the metadata in its header
+func regenerateWorkflow(lib *template.Library, projectPath string, meta models.WorkflowMeta) (models.WorkflowFile, error) {
+	switch meta.Kind {
+	case "notification":
+		return generateNotificationWorkflow(lib, meta.Runner)
+
+	case "deploy":
+		config := models.ProjectConfig{
+			Name:         meta.Name,
+			DeployBranch: meta.DeployBranch,
+			BuildFolder:  meta.BuildFolder,
+			Platform:     meta.Platform,
+			Template:     meta.Template,
+			Vars:         meta.Vars,
+			Preview:      meta.Preview,
+			Verify:       meta.Verify,
+			Runner:       meta.Runner,
+		}
+
+		if err := validateProjectInputs(config); err != nil {
+			return models.WorkflowFile{}, err
+		}
+
+		return generateDeployWorkflow(lib, projectPath, config, meta.Notify)
+
+	default:
+		return models.WorkflowFile{}, fmt.Errorf("unknown workflow kind: %s", meta.Kind)
+	}
+}
+
+// mergeWorkflow merges newly generated output into the current file and
+// writes the result unless it conflicts or this is a dry run. The current file
+// is backed up to backupId, which is picked on the first backup.
+func mergeWorkflow(file models.WorkflowFile, current string, backupId *string, opts models.GenerateOptions) (models.UpdatedFile, error) {
+	// A missing base means every local difference is treated as a conflict
+	base, err := os.ReadFile(basePath(opts.ProjectPath, file.Path))
+	if err != nil && !os.IsNotExist(err) {
+		return models.UpdatedFile{}, fmt.Errorf("failed to read merge base for %s: %w", file.Path, err)
+	}
+
+	merged, conflicts := utils.Merge3(string(base), current, file.Content)
+
+	result := models.UpdatedFile{
+		Path:      file.Path,
+		Conflicts: conflicts,
+		Content:   merged,
+	}
+
+	switch {
+	case conflicts > 0:
+		result.Status = "conflict"
+		return result, nil
+	case merged == current:
+		result.Status = "unchanged"
+	default:
+		result.Status = "updated"
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if result.Status == "updated" {
+		if *backupId == "" {
+			*backupId = newBackupId(opts.ProjectPath)
+		}
+		if err := backupFile(opts.ProjectPath, *backupId, file.Path); err != nil {
+			return models.UpdatedFile{}, err
+		}
+		result.Backup = *backupId
+
+		if err := os.WriteFile(filepath.Join(opts.ProjectPath, file.Path), []byte(merged), 0644); err != nil {
+			return models.UpdatedFile{}, fmt.Errorf("failed to write workflow file %s: %w", file.Path, err)
+		}
+	}
+
+	// Record the new output so the next update merges against it
+	if err := writeBaseFile(opts.ProjectPath, file); err != nil {
+		return models.UpdatedFile{}, err
+	}
+
+	return result, nil
+}
+
+// FormatUpdateResults builds a human readable summary of an update run.
+// In dry-run mode it includes the diff each file would receive.
+func FormatUpdateResults(results []models.UpdatedFile, opts models.GenerateOptions) (string, error) {
+	var b strings.Builder
+
+	if len(results) == 0 {
+		return "No slark-generated workflows found.", nil
+	}
+
+	for _, result := range results {
+		switch result.Status {
+		case "conflict":
+			fmt.Fprintf(&b, "conflict   %s (%d conflicting region(s), left untouched)\n", result.Path, result.Conflicts)
+		default:
+			fmt.Fprintf(&b, "%-10s %s\n", result.Status, result.Path)
+		}
+	}
+
+	if !opts.DryRun {
+		for _, result := range results {
+			if result.Backup != "" {
+				fmt.Fprintf(&b, "\nPrevious versions were backed up to %s, run `slark restore %s` to bring them back.\n",
+					backupPath(result.Backup), result.Backup)
+				break
+			}
+		}
+		return b.String(), nil
+	}
+
+	b.WriteString("\nDry run: no files were written.\n\n")
+	for _, result := range results {
+		if result.Status == "unchanged" {
+			continue
+		}
+
+		current, err := os.ReadFile(filepath.Join(opts.ProjectPath, result.Path))
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", result.Path, err)
+		}
+		b.WriteString(utils.UnifiedDiff("a/"+result.Path, "b/"+result.Path, string(current), result.Content))
+	}
+
+	return b.String(), nil
+}
@@ -3,246 +3,612 @@ package core
 import (
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
+	"slark/internal/actions"
+	"slark/internal/analyzer"
+	"slark/internal/config"
 	"slark/internal/models"
 	"slark/internal/platform"
+	"slark/internal/template"
+	"slark/internal/utils"
+	"slark/internal/workspace"
 )
 
+// DefaultTemplate is the template variant used when none is chosen
+const DefaultTemplate = "basic"
+
 // GenerateWorkflows creates workflow files based on the project configuration
-// and platform-specific settings.
-func GenerateWorkflows(config models.ProjectConfig, platformData models.PlatformData) ([]string, error) {
-	// List to store the paths of generated workflow files
-	var generatedFiles []string
+// and platform-specific settings, and returns them along with the ID of the
+// created platform project. In dry-run mode the files are only rendered and
+// no platform project is created. Files that would replace different ones
+// already in the project fail with an OverwriteError unless opts.Force is
+// set, the replaced files are then backed up.
+func GenerateWorkflows(config models.ProjectConfig, platformData models.PlatformData, opts models.GenerateOptions) ([]models.WorkflowFile, string, error) {
+	// List to store the rendered workflow files
+	var workflowFiles []models.WorkflowFile
+	var projectId string
+
+	// Project and user templates take precedence over the built-in ones
+	lib := template.NewLibrary(opts.ProjectPath)
+
+	if err := validateTemplateFramework(lib, config.Platform+"/"+config.Template, platformData.Framework); err != nil {
+		return nil, "", err
+	}
+
+	// Notifications are wired in when there is a Telegram chat to send to
+	notify := platformData.ChatId != ""
+
+	// Render before creating anything so template errors leave no half setup behind
+	file, err := generateDeployWorkflow(lib, opts.ProjectPath, config, notify)
+	if err != nil {
+		return nil, "", err
+	}
+	workflowFiles = append(workflowFiles, file)
+
+	// Add notification workflows if enabled
+	if notify {
+		runner, err := notifyRunner(lib, opts.ProjectPath, config)
+		if err != nil {
+			return nil, "", err
+		}
+
+		file, err := generateNotificationWorkflow(lib, runner)
+		if err != nil {
+			return nil, "", err
+		}
+		workflowFiles = append(workflowFiles, file)
+	}
+
+	// Compare against the files on disk before anything is written
+	for i, file := range workflowFiles {
+		status, err := fileStatus(opts.ProjectPath, file)
+		if err != nil {
+			return nil, "", err
+		}
+		workflowFiles[i].Status = status
+	}
+
+	if opts.DryRun {
+		return workflowFiles, projectId, nil
+	}
+
+	// Ask before replacing files, including a notification workflow other projects share
+	if !opts.Force {
+		existing, err := ExistingFiles(opts.ProjectPath, workflowFiles)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(existing) > 0 {
+			return nil, "", &OverwriteError{Files: existing}
+		}
+	}
+
+	// Create the project on the platform
+	projectId, err = createPlatformProject(config, platformData)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := writeWorkflowFiles(opts.ProjectPath, workflowFiles); err != nil {
+		return nil, "", err
+	}
+
+	return workflowFiles, projectId, nil
+}
+
+// fileStatus reports whether writing the file creates, modifies or leaves
+// unchanged the file in the project
+func fileStatus(projectPath string, file models.WorkflowFile) (string, error) {
+	current, err := os.ReadFile(filepath.Join(projectPath, file.Path))
+	switch {
+	case os.IsNotExist(err):
+		return "created", nil
+	case err != nil:
+		return "", fmt.Errorf("failed to read %s: %w", file.Path, err)
+	case string(current) == file.Content:
+		return "unchanged", nil
+	default:
+		return "modified", nil
+	}
+}
+
+// DiffWorkflows returns a unified diff between the rendered workflow files
+// and their current contents in the project
+func DiffWorkflows(projectPath string, files []models.WorkflowFile) (string, error) {
+	var b strings.Builder
+
+	for _, file := range files {
+		current, err := os.ReadFile(filepath.Join(projectPath, file.Path))
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to read %s: %w", file.Path, err)
+		}
+
+		// New files are diffed against /dev/null like git does
+		fromName := "a/" + file.Path
+		if os.IsNotExist(err) {
+			fromName = "/dev/null"
+		}
+
+		b.WriteString(utils.UnifiedDiff(fromName, "b/"+file.Path, string(current), file.Content))
+	}
+
+	return b.String(), nil
+}
+
+// writeWorkflowFiles writes rendered workflow files into the project,
+// backing up the files they replace
+func writeWorkflowFiles(projectPath string, files []models.WorkflowFile) error {
+	backupId := ""
+	for i, file := range files {
+		path := filepath.Join(projectPath, file.Path)
+
+		if file.Status == "modified" {
+			if backupId == "" {
+				backupId = newBackupId(projectPath)
+			}
+			if err := backupFile(projectPath, backupId, file.Path); err != nil {
+				return err
+			}
+			files[i].Backup = backupId
+		}
+
+		// Create the workflow directory if it doesn't exist
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return fmt.Errorf("failed to create %s directory: %w", filepath.Dir(file.Path), err)
+		}
+
+		// Write the workflow content to the file
+		err = os.WriteFile(path, []byte(file.Content), 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write workflow file %s: %w", file.Path, err)
+		}
+
+		// Keep the pristine output as the merge base for `slark update`
+		if err := writeBaseFile(projectPath, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// basePath returns where the generated version of a workflow file is kept
+func basePath(projectPath, path string) string {
+	return filepath.Join(projectPath, ".slark", "base", path)
+}
+
+// writeBaseFile stores the generated content of a workflow file as merge base
+func writeBaseFile(projectPath string, file models.WorkflowFile) error {
+	path := basePath(projectPath, file.Path)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s directory: %w", filepath.Dir(path), err)
+	}
+
+	if err := os.WriteFile(path, []byte(file.Content), 0644); err != nil {
+		return fmt.Errorf("failed to write merge base %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// secretPattern matches references to GitHub secrets in workflow expressions
+var secretPattern = regexp.MustCompile(`secrets\.([A-Za-z_][A-Za-z0-9_]*)`)
+
+// WorkflowSecrets returns the sorted names of the GitHub secrets a workflow
+// references, leaving out the GITHUB_TOKEN GitHub provides itself
+func WorkflowSecrets(content string) []string {
+	var secrets []string
+	for _, match := range secretPattern.FindAllStringSubmatch(content, -1) {
+		if match[1] != "GITHUB_TOKEN" && !slices.Contains(secrets, match[1]) {
+			secrets = append(secrets, match[1])
+		}
+	}
+
+	slices.Sort(secrets)
+	return secrets
+}
+
+// headerPrefix starts every metadata line in a generated workflow header
+const headerPrefix = "# slark:"
+
+// workflowHeader returns the comment block that marks a workflow as generated
+// by slark and records the settings needed to regenerate it
+func workflowHeader(meta models.WorkflowMeta) string {
+	var b strings.Builder
+
+	b.WriteString("# Generated by slark. Run `slark update` to regenerate, local edits are merged.\n")
+	fmt.Fprintf(&b, "%s kind: %s\n", headerPrefix, meta.Kind)
+
+	// Workflows without a runner keep the runners of their template
+	if meta.Runner != "" {
+		fmt.Fprintf(&b, "%s runner: %s\n", headerPrefix, meta.Runner)
+	}
+
+	if meta.Kind == "deploy" {
+		fmt.Fprintf(&b, "%s project: %s\n", headerPrefix, meta.Name)
+		fmt.Fprintf(&b, "%s platform: %s\n", headerPrefix, meta.Platform)
+		fmt.Fprintf(&b, "%s branch: %s\n", headerPrefix, meta.DeployBranch)
+		fmt.Fprintf(&b, "%s build-folder: %s\n", headerPrefix, meta.BuildFolder)
+		fmt.Fprintf(&b, "%s template: %s\n", headerPrefix, meta.Template)
+		for _, name := range slices.Sorted(maps.Keys(meta.Vars)) {
+			fmt.Fprintf(&b, "%s var: %s=%s\n", headerPrefix, name, meta.Vars[name])
+		}
+		fmt.Fprintf(&b, "%s notify: %t\n", headerPrefix, meta.Notify)
+		fmt.Fprintf(&b, "%s preview: %t\n", headerPrefix, meta.Preview)
+		fmt.Fprintf(&b, "%s verify: %t\n", headerPrefix, meta.Verify)
+	}
+
+	return b.String()
+}
+
+// ParseWorkflowHeader reads the slark metadata from a workflow file.
+// It reports false when the file was not generated by slark.
+func ParseWorkflowHeader(content string) (models.WorkflowMeta, bool) {
+	var meta models.WorkflowMeta
+
+	for _, line := range strings.Split(content, "\n") {
+		// The header is the leading comment block
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+
+		if !strings.HasPrefix(line, headerPrefix) {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, headerPrefix), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.TrimSpace(key) {
+		case "kind":
+			meta.Kind = value
+		case "project":
+			meta.Name = value
+		case "platform":
+			meta.Platform = value
+		case "branch":
+			meta.DeployBranch = value
+		case "build-folder":
+			meta.BuildFolder = value
+		case "template":
+			meta.Template = value
+		case "var":
+			name, varValue, ok := strings.Cut(value, "=")
+			if !ok {
+				continue
+			}
+			if meta.Vars == nil {
+				meta.Vars = make(map[string]string)
+			}
+			meta.Vars[name] = varValue
+		case "notify":
+			meta.Notify = value == "true"
+		case "preview":
+			meta.Preview = value == "true"
+		case "verify":
+			meta.Verify = value == "true"
+		case "runner":
+			meta.Runner = value
+		}
+	}
+
+	// Workflows generated before variants existed use the basic template
+	if meta.Kind == "deploy" && meta.Template == "" {
+		meta.Template = DefaultTemplate
+	}
+
+	return meta, meta.Kind != ""
+}
+
+// validateTemplateFramework checks that a template limited to some
+// frameworks, by itself or the templates it extends, supports the framework
+// of the project, when one is set
+func validateTemplateFramework(lib *template.Library, templateName, framework string) error {
+	frameworks, err := lib.Frameworks(templateName)
+	if err != nil {
+		return err
+	}
+
+	if framework == "" || len(frameworks) == 0 || slices.Contains(frameworks, framework) {
+		return nil
+	}
+
+	return fmt.Errorf("template %s does not support the %s framework, only %s", templateName, framework, strings.Join(frameworks, ", "))
+}
+
+// generateDeployWorkflow renders the GitHub Actions workflow deploying the
+// project from the template variant of its platform. In a monorepo the
+// workflow also runs when a workspace package the project depends on changes.
+func generateDeployWorkflow(lib *template.Library, projectPath string, config models.ProjectConfig, notify bool) (models.WorkflowFile, error) {
+	vars := templateVars(config, notify)
+
+	content := workflowHeader(models.WorkflowMeta{
+		Kind:         "deploy",
+		Name:         config.Name,
+		Platform:     config.Platform,
+		DeployBranch: config.DeployBranch,
+		BuildFolder:  config.BuildFolder,
+		Template:     config.Template,
+		Vars:         config.Vars,
+		Notify:       notify,
+		Preview:      config.Preview,
+		Verify:       config.Verify,
+		Runner:       config.Runner,
+	})
+
+	templateName := config.Platform + "/" + config.Template
+	values, err := detectedValues(lib, templateName, projectPath, config)
+	if err != nil {
+		return models.WorkflowFile{}, err
+	}
+
+	workflow, err := lib.Render(templateName, vars, values)
+	if err != nil {
+		return models.WorkflowFile{}, err
+	}
+
+	ws, err := workspace.Load(projectPath)
+	if err != nil {
+		return models.WorkflowFile{}, err
+	}
+	dependencies, err := ws.Dependencies(config.BuildFolder)
+	if err != nil {
+		return models.WorkflowFile{}, err
+	}
+	addDependencyPaths(workflow, vars["build_path_filter"], dependencies)
+
+	if err := setRunner(workflow, config.Runner); err != nil {
+		return models.WorkflowFile{}, err
+	}
+
+	deploy, err := workflow.Marshal()
+	if err != nil {
+		return models.WorkflowFile{}, err
+	}
+	content += deploy
+
+	return models.WorkflowFile{Path: ".github/workflows/" + vars["workflow_file"], Content: content}, nil
+}
+
+// detectedValues returns the template variables set from the package manager
+// and Node.js version of the project, for the variables the template
+// declares, completed by the user set variables which take precedence
+func detectedValues(lib *template.Library, templateName, projectPath string, config models.ProjectConfig) (map[string]string, error) {
+	detected, err := analyzedValues(projectPath, config)
+	if err != nil {
+		return nil, err
+	}
+
+	declared, err := lib.Variables(templateName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Templates not declaring a variable don't use it
+	values := make(map[string]string)
+	for _, variable := range declared {
+		if value := detected[variable.Name]; value != "" {
+			values[variable.Name] = value
+		}
+	}
+	maps.Copy(values, config.Vars)
+
+	return values, nil
+}
+
+// analyzedValues returns the template variables slark detects from the
+// package manager, Node.js version and scripts of the project. An empty
+// value leaves the template default in place.
+func analyzedValues(projectPath string, config models.ProjectConfig) (map[string]string, error) {
+	project, err := analyzer.Analyze(projectPath, config.BuildFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	pnpmVersion, err := project.PnpmVersion(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	installFolder := path.Dir(project.Lockfile)
+	if project.Lockfile == "" {
+		installFolder = filepath.ToSlash(config.BuildFolder)
+	}
+
+	return map[string]string{
+		"node_version":     project.NodeVersion,
+		"node_cache":       project.NodeCache(),
+		"lockfile":         project.Lockfile,
+		"install_folder":   installFolder,
+		"install_command":  project.InstallCommand(),
+		"output_folder":    path.Join(filepath.ToSlash(config.BuildFolder), project.OutputDir()),
+		"run_command":      project.RunCommand(),
+		"lint_script":      project.Script("lint"),
+		"typecheck_script": project.Script("typecheck", "type-check", "check-types", "tsc"),
+		"test_script":      project.Script("test"),
+		"setup_pnpm":       strconv.FormatBool(project.PackageManager == analyzer.Pnpm),
+		"pnpm_version":     pnpmVersion,
+		"setup_bun":        strconv.FormatBool(project.PackageManager == analyzer.Bun),
+		"enable_corepack":  strconv.FormatBool(project.YarnBerry),
+	}, nil
+}
+
+// addDependencyPaths adds path filters for the workspace packages in
+// dependencies next to the filter of the project folder. Triggers without the
+// project filter are left alone, adding to them would narrow them down.
+func addDependencyPaths(workflow *actions.Workflow, buildFilter string, dependencies []string) {
+	for _, event := range []*actions.Event{workflow.On.Push, workflow.On.PullRequest} {
+		if event == nil {
+			continue
+		}
+
+		index := slices.Index(event.Paths, buildFilter)
+		if index < 0 {
+			continue
+		}
+
+		var filters []string
+		for _, dir := range dependencies {
+			filter := path.Join(dir, "**")
+			if !slices.Contains(event.Paths, filter) {
+				filters = append(filters, filter)
+			}
+		}
+		event.Paths = slices.Insert(event.Paths, index+1, filters...)
+	}
+}
 
-	// Generate platform-specific workflows
+// createPlatformProject creates the project on its deployment platform and
+// returns the platform's project ID
+func createPlatformProject(config models.ProjectConfig, platformData models.PlatformData) (string, error) {
 	switch config.Platform {
 	case "vercel":
-		files, err := generateVercelWorkflow(config, platformData)
+		// Validate Vercel-specific requirements
+		if platformData.ApiKey == "" {
+			return "", fmt.Errorf("Vercel API key is required")
+		}
+
+		projectId, err := platform.CreateVercelProject(config, platformData)
 		if err != nil {
-			slog.Error("error generating Vercel workflow", "error", err)
-			return nil, err
+			return "", fmt.Errorf("failed to create Vercel project: %w", err)
 		}
-		generatedFiles = append(generatedFiles, files...)
+		return projectId, nil
 
 	case "cloudflare":
-		files, err := generateCloudflareWorkflow(config, platformData)
+		// Validate Cloudflare-specific requirements
+		if platformData.ApiKey == "" {
+			return "", fmt.Errorf("cloudflare API key is required")
+		}
+		if platformData.AccountId == "" {
+			return "", fmt.Errorf("cloudflare account ID is required")
+		}
+
+		projectId, err := platform.CreateCloudflareProject(config, platformData)
 		if err != nil {
-			slog.Error("error generating Cloudflare workflow", "error", err)
-			return nil, err
+			return "", fmt.Errorf("failed to create Cloudflare Pages project: %w", err)
 		}
-		generatedFiles = append(generatedFiles, files...)
+		return projectId, nil
 
 	default:
 		slog.Error("unsupported platform", "platform", config.Platform)
-		return nil, fmt.Errorf("unsupported platform: %s", config.Platform)
+		return "", fmt.Errorf("unsupported platform: %s", config.Platform)
 	}
+}
 
-	// Add notification workflows if enabled
-	if platformData.BotToken != "" && platformData.ChatId != "" {
-		files, err := generateNotificationWorkflow()
-		if err != nil {
-			slog.Error("error generating notification workflow", "error", err)
-			return nil, err
-		}
-		generatedFiles = append(generatedFiles, files...)
-	}
-
-	return generatedFiles, nil
-}
-
-// generateVercelWorkflow creates GitHub Actions workflow files for Vercel deployments
-func generateVercelWorkflow(config models.ProjectConfig, platformData models.PlatformData) ([]string, error) {
-	// Validate Vercel-specific requirements
-	if platformData.ApiKey == "" {
-		return nil, fmt.Errorf("Vercel API key is required")
-	}
-
-	_, err := platform.CreateVercelProject(config, platformData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Vercel project: %w", err)
-	}
-
-	projectIdName := "VERCEL_" + strings.ToUpper(strings.ReplaceAll(config.Name, "-", "_")) + "_" + strings.ToUpper(config.DeployBranch)
-	template := fmt.Sprintf(`
-name: %s - branch %s - GitHub Actions Vercel Deployment
-env:
-  VERCEL_ORG_ID: ${{ secrets.VERCEL_ORG_ID }}
-  VERCEL_PROJECT_ID: ${{ secrets.%s }}
-on:
-  push:
-    branches:
-      - %s
-    paths:
-      - %s**
-      - .github/workflows/%s.%s.yml
-jobs:
-  Deploy-Production:
-    runs-on: self-hosted
-    steps:
-      - uses: actions/checkout@v3
-      - uses: actions/setup-node@v4
-        with:
-          node-version: 22
-      - name: Install Vercel CLI
-        run: | 
-          npm install --global vercel@canary
-          npm install -g pnpm
-      - name: Pull Vercel Environment Information
-        run: vercel pull --yes --environment=production --token=${{ secrets.VERCEL_TOKEN }}
-      - name: Build Project Artifacts
-        id: build
-        run: vercel build --prod --token=${{ secrets.VERCEL_TOKEN }}
-
-      - name: Deploy Project Artifacts to Vercel
-        id: deploy
-        run: vercel deploy --prebuilt --prod --token=${{ secrets.VERCEL_TOKEN }}
-
-      - name: "set result"
-        id: deploy-task-result
-        if: always()
-        run: |
-          if ${{ steps.build.outcome == 'success' && (steps.deploy.outcome == 'success' || steps.deploy.outcome == null) }}; then # Check both build and deploy
-            echo "deploy_result=success" >> "$GITHUB_OUTPUT"
-          else
-            echo "deploy_result=failure" >> "$GITHUB_OUTPUT"
-          fi
-    outputs:
-      deploy_result: ${{ steps.deploy-task-result.outputs.deploy_result }}
-    `, config.Name, config.DeployBranch, projectIdName, config.DeployBranch, config.BuildFolder, config.Name, config.DeployBranch)
-
-	//if telegram token is set append this to above string
-	if platformData.BotToken != "" && platformData.ChatId != "" {
-		template += fmt.Sprintf(`
-  noti-tele:
-    name: Notify Telegram
-    uses: "./.github/workflows/.telegram-noti.yml"
-    needs: Deploy-Production
-    if: |
-      always()
-    with:
-      main_job_name: Deploy-Production
-      results: Deploy ${{ needs.Deploy-Production.outputs.deploy_result }}
-      service_name: %s
-      `, config.Name)
-	}
-
-	// Define the workflow file path
-	workflowPath := fmt.Sprintf(`.github/workflows/%s.%s.yml`, config.Name, config.DeployBranch)
-
-	err = os.MkdirAll(".github/workflows", 0755)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create .github/workflows directory: %w", err)
-	}
-
-	// Write the workflow content to the file
-	err = os.WriteFile(workflowPath, []byte(template), 0644)
+// notifyRunner returns the runner of the shared notification workflow, the
+// one saved in the config file so every project renders it the same way.
+// Until one is saved it runs where the jobs of the project run.
+func notifyRunner(lib *template.Library, projectPath string, project models.ProjectConfig) (string, error) {
+	cfg, err := config.Load(projectPath)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	if cfg.NotifyRunner != "" {
+		return cfg.NotifyRunner, nil
+	}
+
+	// Keep the runner of a notification workflow set up before it was saved
+	current, err := os.ReadFile(filepath.Join(projectPath, workflowsDir, notificationWorkflow))
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read %s: %w", notificationWorkflow, err)
+	}
+	if meta, ok := ParseWorkflowHeader(string(current)); ok && meta.Runner != "" {
+		return meta.Runner, nil
 	}
 
-	return []string{workflowPath}, nil
-}
-
-// generateCloudflareWorkflow creates GitHub Actions workflow files for Cloudflare deployments
-func generateCloudflareWorkflow(config models.ProjectConfig, platformData models.PlatformData) ([]string, error) {
-	// Validate Cloudflare-specific requirements
-	if platformData.ApiKey == "" {
-		return nil, fmt.Errorf("cloudflare API key is required")
-	}
-
-	// Create workflow content
-	// Marked as _ to avoid unused variable warning while keeping the code for reference
-	_ = fmt.Sprintf(`
-name: Deploy to Cloudflare Pages
-
-on:
-  push:
-    branches:
-      - %s
-
-jobs:
-  deploy:
-    runs-on: ubuntu-latest
-    steps:
-      - uses: actions/checkout@v3
-      
-      - name: Setup Node.js
-        uses: actions/setup-node@v3
-        with:
-          node-version: '18'
-          
-      - name: Deploy to Cloudflare Pages
-        uses: cloudflare/pages-action@v1
-        with:
-          apiToken: ${{ secrets.CLOUDFLARE_API_TOKEN }}
-          accountId: ${{ secrets.CLOUDFLARE_ACCOUNT_ID }}
-          projectName: %s
-          directory: %s
-          gitHubToken: ${{ secrets.GITHUB_TOKEN }}
-`, config.DeployBranch, config.Name, config.BuildFolder)
-
-	// Define the workflow file path
-	workflowPath := ".github/workflows/cloudflare-deploy.yml"
-
-	// TODO: In a real implementation, write this content to the file
-	// For now, just return the path that would be created
-
-	return []string{workflowPath}, nil
-}
-
-// generateNotificationWorkflow creates workflow files for notifications
-func generateNotificationWorkflow() ([]string, error) {
-	// Create workflow content from notification template
-	template := `on:
-  workflow_call:
-    inputs:
-      main_job_name:
-        required: true
-        type: string
-      results:
-        required: true
-        type: string
-      service_name:
-        required: true
-        type: string
-      dev_id:
-        required: false
-        type: string
-        default: "U061KBS9HDK"
-jobs:
-  telegram_message:
-    runs-on: self-hosted
-    if: always()
-    steps:
-      - name: send telegram message on push
-        uses: appleboy/telegram-action@master
-        with:
-          to: ${{ secrets.TELEGRAM_CHAT_ID }}
-          token: ${{ secrets.TELEGRAM_BOT_TOKEN }}
-          message: |
-            ${{ github.actor }} created commit:
-            Commit message: ${{ github.event.commits[0].message }}
-            Repository: ${{ github.repository }}
-            Project: ${{ inputs.service_name }}
-            GitHub Action build result: ${{ inputs.results }}
-            See changes: https://github.com/${{ github.repository }}/commit/${{github.sha}}`
-
-	// Define the workflow file path
-	workflowPath := ".github/workflows/.telegram-noti.yml"
-
-	// Create the .github/workflows directory if it doesn't exist
-	err := os.MkdirAll(".github/workflows", 0755)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create .github/workflows directory: %w", err)
-	}
-
-	// Write the workflow content to the file
-	err = os.WriteFile(workflowPath, []byte(template), 0644)
-	if err != nil {
-		return nil, fmt.Errorf("failed to write notification workflow file: %w", err)
-	}
-
-	return []string{workflowPath}, nil
+	if project.Runner != "" {
+		return project.Runner, nil
+	}
+	if runner := project.Vars["runs_on"]; runner != "" {
+		return runner, nil
+	}
+
+	// Otherwise the jobs run on the runs_on default of the template
+	variables, err := lib.Variables(project.Platform + "/" + project.Template)
+	if err != nil {
+		return "", err
+	}
+	for _, variable := range variables {
+		if variable.Name == "runs_on" {
+			return variable.Default, nil
+		}
+	}
+
+	return "", nil
+}
+
+// generateNotificationWorkflow renders the shared workflow used for
+// notifications, on the given runner
+func generateNotificationWorkflow(lib *template.Library, runner string) (models.WorkflowFile, error) {
+	workflow, err := lib.Render("notifications/telegram", nil, nil)
+	if err != nil {
+		return models.WorkflowFile{}, err
+	}
+
+	if err := setRunner(workflow, runner); err != nil {
+		return models.WorkflowFile{}, err
+	}
+
+	content, err := workflow.Marshal()
+	if err != nil {
+		return models.WorkflowFile{}, err
+	}
+
+	return models.WorkflowFile{
+		Path:    workflowsDir + "/" + notificationWorkflow,
+		Content: workflowHeader(models.WorkflowMeta{Kind: "notification", Runner: runner}) + content,
+	}, nil
+}
+
+// setRunner runs every job of the workflow on the runner, an empty runner
+// keeps the runners of the template
+func setRunner(workflow *actions.Workflow, runner string) error {
+	if runner == "" {
+		return nil
+	}
+
+	runsOn, err := actions.ParseRunsOn(runner)
+	if err != nil {
+		return err
+	}
+	workflow.SetRunsOn(runsOn)
+
+	return nil
+}
+
+// Characters that cannot be used in workflow file names and secret names
+var (
+	unsafeFileChars   = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+	unsafeSecretChars = regexp.MustCompile(`[^A-Z0-9]+`)
+)
+
+// templateVars returns the values of the template variables slark sets from
+// the project settings
+func templateVars(config models.ProjectConfig, notify bool) map[string]string {
+	folder := filepath.ToSlash(config.BuildFolder)
+
+	return map[string]string{
+		"notify":            strconv.FormatBool(notify),
+		"preview":           strconv.FormatBool(config.Preview),
+		"verify":            strconv.FormatBool(config.Verify),
+		"project_name":      config.Name,
+		"deploy_branch":     config.DeployBranch,
+		"build_folder":      folder,
+		"build_path_filter": path.Join(folder, "**"),
+		"project_id_secret": "VERCEL_" + unsafeSecretChars.ReplaceAllString(strings.ToUpper(config.Name+"_"+config.DeployBranch), "_"),
+		"workflow_file":     unsafeFileChars.ReplaceAllString(config.Name+"."+config.DeployBranch, "-") + ".yml",
+	}
 }
@@ -0,0 +1,169 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"slark/internal/actions"
+	"slark/internal/config"
+	"slark/internal/models"
+	"slark/internal/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDeployWorkflowRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		branch string
+		folder string
+	}{
+		{"web", "main", "./"},
+		{"my:app", "main", "apps/web"},
+		{"app #1", "release/v1", "apps/web/site"},
+		{`it's "quoted"`, "feature: x", "apps/my app"},
+		{"ウェブ-app", "main", "packages/ウェブ"},
+		{"- dash", "*", "a/b/c/d"},
+		{"  padded  ", " main ", "apps/web/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectPath := t.TempDir()
+			lib := template.NewLibrary(projectPath)
+
+			for _, platform := range []string{"vercel", "cloudflare"} {
+				config, err := SetupProject(models.ProjectConfig{
+					Name:         tt.name,
+					DeployBranch: tt.branch,
+					BuildFolder:  tt.folder,
+					Platform:     platform,
+					Vars:         map[string]string{"node_version": "20 # not a comment"},
+				})
+				if err != nil {
+					t.Fatalf("SetupProject: %v", err)
+				}
+
+				file, err := generateDeployWorkflow(lib, projectPath, config, true)
+				if err != nil {
+					t.Fatalf("%s: generateDeployWorkflow: %v", platform, err)
+				}
+
+				meta, ok := ParseWorkflowHeader(file.Content)
+				if !ok {
+					t.Fatalf("%s: header does not parse:\n%s", platform, file.Content)
+				}
+				want := models.WorkflowMeta{
+					Kind:         "deploy",
+					Name:         config.Name,
+					Platform:     platform,
+					DeployBranch: config.DeployBranch,
+					BuildFolder:  config.BuildFolder,
+					Template:     DefaultTemplate,
+					Vars:         config.Vars,
+					Notify:       true,
+				}
+				if !metaEqual(meta, want) {
+					t.Errorf("%s: header = %+v, want %+v", platform, meta, want)
+				}
+
+				var workflow actions.Workflow
+				if err := yaml.Unmarshal([]byte(file.Content), &workflow); err != nil {
+					t.Fatalf("%s: body is not valid YAML: %v\n%s", platform, err, file.Content)
+				}
+				if !strings.HasPrefix(workflow.Name, config.Name+" - branch "+config.DeployBranch) {
+					t.Errorf("%s: workflow name = %q", platform, workflow.Name)
+				}
+				if workflow.On.Push == nil || len(workflow.On.Push.Branches) != 1 || workflow.On.Push.Branches[0] != config.DeployBranch {
+					t.Errorf("%s: push branches = %+v, want %q", platform, workflow.On.Push, config.DeployBranch)
+				}
+				if filter := templateVars(config, true)["build_path_filter"]; workflow.On.Push == nil || workflow.On.Push.Paths[0] != filter {
+					t.Errorf("%s: push paths = %+v, want %q first", platform, workflow.On.Push, filter)
+				}
+				if workflow.Job("Deploy-Production") == nil || workflow.Job("noti-tele") == nil {
+					t.Errorf("%s: jobs = %+v", platform, workflow.Jobs)
+				}
+
+				// Regenerating from the header reproduces the file
+				regenerated, err := regenerateWorkflow(lib, projectPath, meta)
+				if err != nil {
+					t.Fatalf("%s: regenerateWorkflow: %v", platform, err)
+				}
+				if regenerated.Path != file.Path || regenerated.Content != file.Content {
+					t.Errorf("%s: regenerated workflow differs:\n%s\nwant:\n%s", platform, regenerated.Content, file.Content)
+				}
+			}
+		})
+	}
+}
+
+func TestSetupProjectRejectsUnsafeSettings(t *testing.T) {
+	tests := []struct {
+		desc   string
+		config models.ProjectConfig
+	}{
+		{"newline in name", models.ProjectConfig{Name: "evil\njobs: {}", DeployBranch: "main", BuildFolder: "."}},
+		{"carriage return in branch", models.ProjectConfig{Name: "web", DeployBranch: "ma\rin", BuildFolder: "."}},
+		{"control character in folder", models.ProjectConfig{Name: "web", DeployBranch: "main", BuildFolder: "apps/\x00web"}},
+		{"absolute folder", models.ProjectConfig{Name: "web", DeployBranch: "main", BuildFolder: "/srv/web"}},
+		{"parent folder", models.ProjectConfig{Name: "web", DeployBranch: "main", BuildFolder: ".."}},
+		{"folder outside the repository", models.ProjectConfig{Name: "web", DeployBranch: "main", BuildFolder: "apps/../../web"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			tt.config.Platform = "vercel"
+			if _, err := SetupProject(tt.config); err == nil {
+				t.Errorf("SetupProject(%+v) succeeded, want an error", tt.config)
+			}
+		})
+	}
+}
+
+// metaEqual compares workflow metadata, treating nil and empty variables alike
+func metaEqual(a, b models.WorkflowMeta) bool {
+	if len(a.Vars) != len(b.Vars) {
+		return false
+	}
+	for name, value := range a.Vars {
+		if b.Vars[name] != value {
+			return false
+		}
+	}
+	a.Vars, b.Vars = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+func TestNotifyRunner(t *testing.T) {
+	tests := []struct {
+		desc    string
+		saved   string
+		project models.ProjectConfig
+		want    string
+	}{
+		{"vercel template default", "", models.ProjectConfig{Platform: "vercel"}, "self-hosted"},
+		{"cloudflare template default", "", models.ProjectConfig{Platform: "cloudflare"}, "ubuntu-latest"},
+		{"runs_on variable", "", models.ProjectConfig{Platform: "vercel", Vars: map[string]string{"runs_on": "ubuntu-24.04"}}, "ubuntu-24.04"},
+		{"project runner", "", models.ProjectConfig{Platform: "cloudflare", Runner: "self-hosted,linux"}, "self-hosted,linux"},
+		{"saved runner", "group:deploy", models.ProjectConfig{Platform: "cloudflare", Runner: "self-hosted"}, "group:deploy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			projectPath := t.TempDir()
+			if err := config.Save(projectPath, &config.Config{NotifyRunner: tt.saved}); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.project.Template = DefaultTemplate
+			got, err := notifyRunner(template.NewLibrary(projectPath), projectPath, tt.project)
+			if err != nil {
+				t.Fatalf("notifyRunner: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("notifyRunner = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
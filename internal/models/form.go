@@ -4,23 +4,29 @@ import (
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 )
 
 type Model struct {
-	Form    *huh.Form
-	Spinner spinner.Model
-	Stage   int // 0: form, 1: processing, 2: results
-	Err     error
-	Success bool
-	Result  string
-	Width   int
-	Height  int
+	Form        *huh.Form
+	Spinner     spinner.Model
+	Stage       int // 0: form, 1: processing, 2: results, 3: confirm overwriting existing files
+	Err         error
+	Success     bool
+	Result      string
+	Width       int
+	Height      int
+	ProjectPath string            // Repository being configured
+	Vars        map[string]string // Saved template variables, the form doesn't ask for them
+	Existing    []ExistingFile    // Files waiting for confirmation before they are overwritten
+	Overwrite   tea.Cmd           // Runs the setup again overwriting existing files
 }
 
 type PlatformData struct {
 	ApiKey    string
 	TeamId    string
+	AccountId string // Cloudflare account ID
 	BotToken  string
 	ChatId    string
 	Framework string // Framework option for Vercel projects
@@ -34,9 +40,102 @@ type ProcessFinishedMsg struct {
 
 // ProjectConfig represents the configuration for a project setup
 type ProjectConfig struct {
+	Name         string            `json:"name"`
+	DeployBranch string            `json:"deployBranch"`
+	BuildFolder  string            `json:"buildFolder"`
+	Platform     string            `json:"platform"`
+	Template     string            `json:"template"`         // Template variant, e.g. basic or advanced
+	Vars         map[string]string `json:"vars,omitempty"`   // User set template variables
+	Preview      bool              `json:"preview"`          // Deploy a preview of every pull request
+	Verify       bool              `json:"verify"`           // Lint, typecheck and test before deploying
+	Runner       string            `json:"runner,omitempty"` // Runners of every job, e.g. ubuntu-latest, self-hosted,linux or group:name
+	CreatedAt    time.Time         `json:"createdAt"`
+}
+
+// GenerateOptions controls how workflows are generated and applied
+type GenerateOptions struct {
+	ProjectPath string // Repository to configure, empty means the working directory
+	DryRun      bool   // Render files in memory and report a diff instead of writing them
+	Force       bool   // Overwrite existing workflow files that differ from the generated ones
+}
+
+// WorkflowFile is a rendered workflow file and the path it is written to
+type WorkflowFile struct {
+	Path    string
+	Content string
+	Status  string // "created", "modified" or "unchanged" compared to the file on disk
+	Backup  string // Backup of the file it replaced, if any
+}
+
+// ExistingFile is a workflow file in the project that generating would replace
+type ExistingFile struct {
+	Path      string `json:"path"`
+	Generated bool   `json:"generated"`       // Whether slark generated the file
+	Owner     string `json:"owner,omitempty"` // Project and branch of a generated deploy workflow
+}
+
+// Backup is a set of workflow files saved before slark replaced or removed them
+type Backup struct {
+	Id    string   `json:"id"` // Time the backup was taken, e.g. 20260102-150405
+	Files []string `json:"files"`
+}
+
+// WorkflowMeta is the slark metadata recorded in the header of a generated workflow
+type WorkflowMeta struct {
+	Kind         string // "deploy" or "notification"
 	Name         string
+	Platform     string
 	DeployBranch string
 	BuildFolder  string
-	Platform     string
-	CreatedAt    time.Time
+	Template     string            // Template variant the workflow was rendered from
+	Vars         map[string]string // User set template variables
+	Notify       bool
+	Preview      bool   // Whether pull requests get preview deployments
+	Verify       bool   // Whether a Verify job gates the deployment
+	Runner       string // Runners of every job, empty when the template picks them
+}
+
+// UpdatedFile describes the outcome of regenerating a single workflow file
+type UpdatedFile struct {
+	Path      string `json:"path"`
+	Status    string `json:"status"` // "unchanged", "updated" or "conflict"
+	Conflicts int    `json:"conflicts"`
+	Backup    string `json:"backup,omitempty"` // Backup of the file before it was updated
+	Content   string `json:"-"`                // Merged content, including conflict markers on conflict
+}
+
+// Check is the outcome of a single doctor diagnostic
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "pass", "warn" or "fail"
+	Detail string `json:"detail"`
+	Hint   string `json:"hint,omitempty"` // Remediation shown when the check does not pass
+}
+
+// RemoveResult describes what removing a pipeline deleted
+type RemoveResult struct {
+	Platform       string   `json:"platform"`
+	RemovedFiles   []string `json:"removedFiles"`
+	ProjectDeleted bool     `json:"projectDeleted"`   // Whether the platform project was deleted
+	UnusedSecrets  []string `json:"unusedSecrets"`    // GitHub secrets no remaining workflow references
+	Warnings       []string `json:"warnings"`         // Parts of the teardown that were skipped and why
+	Backup         string   `json:"backup,omitempty"` // Backup of the removed files
+}
+
+// Pipeline is a slark-generated deploy workflow found in the repository
+type Pipeline struct {
+	Path          string            `json:"path"`
+	Name          string            `json:"name"`
+	Platform      string            `json:"platform"`
+	DeployBranch  string            `json:"deployBranch"`
+	BuildFolder   string            `json:"buildFolder"`
+	Template      string            `json:"template"`
+	Vars          map[string]string `json:"vars,omitempty"`   // User set template variables
+	PathFilters   []string          `json:"pathFilters"`      // Paths that trigger the workflow on push
+	Previews      bool              `json:"previews"`         // Whether pull requests get preview deployments
+	Verify        bool              `json:"verify"`           // Whether a Verify job gates the deployment
+	Runner        string            `json:"runner,omitempty"` // Runners set for every job, empty when the template picks them
+	Notifications string            `json:"notifications"`    // How notifications are wired, e.g. "telegram" or "none"
+	Secrets       []string          `json:"secrets"`          // GitHub secrets the workflow and the workflows it calls expect
+	Live          string            `json:"live,omitempty"`   // Live platform state, only filled in on request
 }
@@ -0,0 +1,47 @@
+package models
+
+// Result is the structured outcome of setting up a project, shared by the
+// text and JSON output of the init command
+type Result struct {
+	Project           ProjectConfig `json:"project"`
+	PlatformProjectId string        `json:"platformProjectId,omitempty"` // ID of the project created on the platform
+	DryRun            bool          `json:"dryRun"`
+	Files             []FileResult  `json:"files"`
+	SecretsRequired   []string      `json:"secretsRequired"` // GitHub secrets the generated workflows expect
+	Warnings          []string      `json:"warnings"`
+	ConfigPath        string        `json:"configPath,omitempty"` // Config file the setup was saved to
+	Backup            string        `json:"backup,omitempty"`     // Backup of the files that were replaced
+	Diff              string        `json:"diff,omitempty"`       // Changes a dry run would make
+}
+
+// FileResult is the outcome for a single generated file
+type FileResult struct {
+	Path   string `json:"path"`
+	Status string `json:"status"` // "created", "modified" or "unchanged"
+}
+
+// TemplateInfo describes a workflow template that can be listed or shown
+type TemplateInfo struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`           // Name used to show the template, e.g. vercel/basic
+	Source   string `json:"source"`         // "project", "user" or "built-in"
+	File     string `json:"file,omitempty"` // File the template is read from, empty for built-ins
+	TemplateMeta
+}
+
+// TemplateMeta is the front-matter block at the top of a template
+type TemplateMeta struct {
+	Description string             `yaml:"description" json:"description"`
+	Platform    string             `yaml:"platform,omitempty" json:"platform,omitempty"`
+	Frameworks  []string           `yaml:"frameworks,omitempty" json:"frameworks,omitempty"` // Empty means any framework
+	Variables   []TemplateVariable `yaml:"variables,omitempty" json:"variables"`
+}
+
+// TemplateVariable is a variable a template can be rendered with
+type TemplateVariable struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty" json:"required"`
+	Default     string `yaml:"default,omitempty" json:"default,omitempty"`
+}
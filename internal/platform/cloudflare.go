@@ -0,0 +1,137 @@
+package platform
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"slark/internal/models"
+)
+
+// cloudflareAPIURL is the base URL of the Cloudflare v4 API
+const cloudflareAPIURL = "https://api.cloudflare.com/client/v4"
+
+// cloudflareResponse is the envelope every Cloudflare API response is wrapped in
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result json.RawMessage `json:"result"`
+}
+
+// CreateCloudflareProject creates a Cloudflare Pages project deploying the
+// project's branch to production and returns the project ID. A project with
+// the same name that already exists is reused.
+func CreateCloudflareProject(config models.ProjectConfig, platformData models.PlatformData) (string, error) {
+	if platformData.AccountId == "" {
+		return "", fmt.Errorf("cloudflare account ID is required")
+	}
+
+	requestURL := fmt.Sprintf("%s/accounts/%s/pages/projects",
+		cloudflareAPIURL, url.PathEscape(platformData.AccountId))
+
+	jsonData, err := json.Marshal(map[string]any{
+		"name":              config.Name,
+		"production_branch": config.DeployBranch,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal project data: %w", err)
+	}
+
+	result, err := doCloudflare("POST", requestURL, platformData.ApiKey, jsonData)
+
+	// The project was set up before, keep it
+	var apiErr *cloudflareError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+		result, err = doCloudflare("GET", requestURL+"/"+url.PathEscape(config.Name), platformData.ApiKey, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to get project, %w", err)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create project, %w", err)
+	}
+
+	var project struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal(result, &project); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return project.Id, nil
+}
+
+// DeleteCloudflareProject deletes the Cloudflare Pages project with the given name
+func DeleteCloudflareProject(projectName string, platformData models.PlatformData) error {
+	if platformData.AccountId == "" {
+		return fmt.Errorf("cloudflare account ID is required")
+	}
+
+	requestURL := fmt.Sprintf("%s/accounts/%s/pages/projects/%s",
+		cloudflareAPIURL, url.PathEscape(platformData.AccountId), url.PathEscape(projectName))
+
+	if _, err := doCloudflare("DELETE", requestURL, platformData.ApiKey, nil); err != nil {
+		return fmt.Errorf("failed to delete project, %w", err)
+	}
+
+	return nil
+}
+
+// cloudflareError is a failed Cloudflare API response
+type cloudflareError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *cloudflareError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("status code: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("status code: %d, message: %s", e.StatusCode, e.Message)
+}
+
+// doCloudflare sends an authenticated request to the Cloudflare API and
+// returns the result of a successful response
+func doCloudflare(method, requestURL, apiKey string, body []byte) (json.RawMessage, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, requestURL, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response cloudflareResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	if !response.Success {
+		apiErr := &cloudflareError{StatusCode: resp.StatusCode}
+		if len(response.Errors) > 0 {
+			apiErr.Message = response.Errors[0].Message
+		}
+		return nil, apiErr
+	}
+
+	return response.Result, nil
+}
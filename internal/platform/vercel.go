@@ -4,11 +4,45 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"strings"
 	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
 	"slark/internal/models"
 )
 
+// vercelAPIURL is the base URL of the Vercel REST API
+const vercelAPIURL = "https://api.vercel.com"
+
+// VercelUser is the account a Vercel API token belongs to
+type VercelUser struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// VercelProject is the live state of a Vercel project
+type VercelProject struct {
+	Id                string             `json:"id"`
+	Name              string             `json:"name"`
+	Framework         string             `json:"framework"`
+	LatestDeployments []VercelDeployment `json:"latestDeployments"`
+}
+
+// VercelDeployment is a single deployment of a Vercel project
+type VercelDeployment struct {
+	URL        string `json:"url"`
+	ReadyState string `json:"readyState"`
+}
+
+// VercelTeam is a Vercel team projects can be created in
+type VercelTeam struct {
+	Id   string `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// CreateVercelProject creates the Vercel project and returns its ID. A project
+// with the same name that already exists is reused.
 func CreateVercelProject(config models.ProjectConfig, platformData models.PlatformData) (string, error) {
 	baseURL := "https://api.vercel.com/v11/projects"
 
@@ -34,8 +68,10 @@ func CreateVercelProject(config models.ProjectConfig, platformData models.Platfo
 		},
 	}
 
-	if strings.Compare(config.BuildFolder, "./") == 0{
-		projectData["rootDirectory"] = config.BuildFolder
+	// Apps in a subfolder are built from there, vercel build reads the root
+	// directory from the project settings it pulls
+	if folder := path.Clean(filepath.ToSlash(config.BuildFolder)); folder != "." {
+		projectData["rootDirectory"] = folder
 	}
 
 	jsonData, err := json.Marshal(projectData)
@@ -57,7 +93,15 @@ func CreateVercelProject(config models.ProjectConfig, platformData models.Platfo
 		return "", fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
-	// body, err := io.ReadAll(resp.Body)
+
+	// The project was set up before, keep it
+	if resp.StatusCode == http.StatusConflict {
+		project, err := GetVercelProject(config.Name, platformData)
+		if err != nil {
+			return "", err
+		}
+		return project.Id, nil
+	}
 
 	// Handle response status codes
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
@@ -71,15 +115,123 @@ func CreateVercelProject(config models.ProjectConfig, platformData models.Platfo
 		return "", fmt.Errorf("failed to create project, status code: %d", resp.StatusCode)
 	}
 
-	fmt.Printf("response body: %s\n", resp.Body)
+	var project struct {
+		Id string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
 
-	return "", nil
+	return project.Id, nil
 }
 
-func DeleteVercelProject(projectName, deployBranch, buildFolder string) error {
+// DeleteVercelProject deletes the Vercel project with the given name
+func DeleteVercelProject(projectName string, platformData models.PlatformData) error {
+	requestURL := fmt.Sprintf("%s/v9/projects/%s", vercelAPIURL, url.PathEscape(projectName))
+	if platformData.TeamId != "" && platformData.TeamId != "team_xxxx" {
+		requestURL = fmt.Sprintf("%s?teamId=%s", requestURL, url.QueryEscape(platformData.TeamId))
+	}
+
+	req, err := http.NewRequest("DELETE", requestURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+platformData.ApiKey)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to delete project, %w", vercelError(resp))
+	}
+
 	return nil
 }
 
-func GetVercelProject(projectName, deployBranch, buildFolder string) error {
+// GetVercelProject returns the live state of the Vercel project with the given name
+func GetVercelProject(projectName string, platformData models.PlatformData) (VercelProject, error) {
+	path := "/v9/projects/" + url.PathEscape(projectName)
+	if platformData.TeamId != "" && platformData.TeamId != "team_xxxx" {
+		path = fmt.Sprintf("%s?teamId=%s", path, url.QueryEscape(platformData.TeamId))
+	}
+
+	var project VercelProject
+	if err := getVercel(path, platformData.ApiKey, &project); err != nil {
+		return VercelProject{}, fmt.Errorf("failed to get project, %w", err)
+	}
+
+	return project, nil
+}
+
+// GetVercelUser returns the account the API token belongs to, which also
+// verifies that the token is valid
+func GetVercelUser(apiKey string) (VercelUser, error) {
+	var response struct {
+		User VercelUser `json:"user"`
+	}
+
+	if err := getVercel("/v2/user", apiKey, &response); err != nil {
+		return VercelUser{}, err
+	}
+
+	return response.User, nil
+}
+
+// GetVercelTeam looks up a team by ID or slug with the given API token
+func GetVercelTeam(apiKey, teamId string) (VercelTeam, error) {
+	var team VercelTeam
+
+	if err := getVercel("/v2/teams/"+url.PathEscape(teamId), apiKey, &team); err != nil {
+		return VercelTeam{}, err
+	}
+
+	return team, nil
+}
+
+// getVercel sends an authenticated GET request to the Vercel API and decodes
+// the JSON response into out
+func getVercel(path, apiKey string, out any) error {
+	req, err := http.NewRequest("GET", vercelAPIURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return vercelError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
 	return nil
 }
+
+// vercelError builds an error from a failed Vercel API response
+func vercelError(resp *http.Response) error {
+	var errorResponse struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&errorResponse); err == nil && errorResponse.Error.Message != "" {
+		return fmt.Errorf("status code: %d, message: %s", resp.StatusCode, errorResponse.Error.Message)
+	}
+
+	return fmt.Errorf("status code: %d", resp.StatusCode)
+}
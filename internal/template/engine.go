@@ -0,0 +1,385 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Tags understood by the template engine:
+//
+//	{{name}}                     the value of a variable
+//	{{> name}}                   the partial partials/<name>
+//	{{#if name}}...{{else}}...{{/if}}  content kept when the variable is set and not "false"
+//	{{extends name}}             render the named template with this template's blocks
+//	{{#block name}}...{{/block}} content a template extending this one can replace
+//
+// GitHub Actions expressions like ${{ secrets.X }} are told apart by the
+// leading $ and left alone. A tag other than a variable on a line of its own
+// removes the whole line, and a partial on a line of its own is indented to
+// the column of the tag.
+var tagPattern = regexp.MustCompile(`\{\{(?:([a-z_][a-z0-9_]*)|> *([a-z0-9_/-]+)|#if +([a-z_][a-z0-9_]*)|(else)|(/if)|#block +([a-z0-9_-]+)|(/block)|extends +([a-z0-9_/-]+))\}\}`)
+
+// tokenKind is the kind of a lexed template token, numbered like the tag pattern groups
+type tokenKind int
+
+const (
+	textToken tokenKind = iota
+	variableToken
+	partialToken
+	ifToken
+	elseToken
+	endIfToken
+	blockToken
+	endBlockToken
+	extendsToken
+)
+
+// token is a piece of template text or a tag
+type token struct {
+	kind       tokenKind
+	value      string // Text, or the name in the tag
+	tag        string // The tag as written, for error messages
+	standalone bool   // The tag sits on a line of its own
+	indent     string // Indentation of a standalone tag
+}
+
+// nodeKind is the kind of a parsed template node
+type nodeKind int
+
+const (
+	textNode nodeKind = iota
+	variableNode
+	partialNode
+	ifNode
+	blockNode
+)
+
+// node is a parsed piece of a template
+type node struct {
+	kind       nodeKind
+	value      string // Text, or the name of the variable, partial, condition or block
+	standalone bool   // The partial sits on a line of its own
+	indent     string // Indentation of a standalone partial
+	children   []node // Content of an if or block node
+	others     []node // Content of the else branch of an if node
+}
+
+// parsedTemplate is a template split into nodes
+type parsedTemplate struct {
+	extends string // Template this one extends, its nodes are then only blocks
+	nodes   []node
+}
+
+// tokenize splits a template into text and tags
+func tokenize(text string) []token {
+	var tokens []token
+	last := 0
+
+	for _, match := range tagPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[0], match[1]
+
+		// Leave GitHub Actions expressions alone
+		if start > 0 && text[start-1] == '$' {
+			continue
+		}
+
+		tok := token{tag: text[start:end]}
+		for group := 1; group < len(match)/2; group++ {
+			if match[2*group] >= 0 {
+				tok.kind = tokenKind(group)
+				tok.value = text[match[2*group]:match[2*group+1]]
+				break
+			}
+		}
+
+		// else is also a valid variable name, the keyword wins
+		if tok.kind == variableToken && tok.value == "else" {
+			tok.kind = elseToken
+		}
+
+		// Tags on a line of their own take the whole line with them
+		if tok.kind != variableToken {
+			lineStart := strings.LastIndexByte(text[:start], '\n') + 1
+			lineEnd := strings.IndexByte(text[end:], '\n')
+			if lineEnd < 0 {
+				lineEnd = len(text) - end
+			} else {
+				lineEnd++
+			}
+
+			prefix := text[lineStart:start]
+			suffix := text[end : end+lineEnd]
+			if lineStart >= last && strings.TrimLeft(prefix, " \t") == "" && strings.TrimSpace(suffix) == "" {
+				tok.standalone = true
+				tok.indent = prefix
+				start = lineStart
+				end += lineEnd
+			}
+		}
+
+		if start > last {
+			tokens = append(tokens, token{kind: textToken, value: text[last:start]})
+		}
+		tokens = append(tokens, tok)
+		last = end
+	}
+
+	if last < len(text) {
+		tokens = append(tokens, token{kind: textToken, value: text[last:]})
+	}
+
+	return tokens
+}
+
+// parse turns a template body into nodes
+func parse(name, text string) (parsedTemplate, error) {
+	p := &parser{name: name, tokens: tokenize(text)}
+
+	// An extends tag must come first
+	var result parsedTemplate
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		if tok.kind == textToken && strings.TrimSpace(tok.value) == "" {
+			p.pos++
+			continue
+		}
+		if tok.kind == extendsToken {
+			result.extends = tok.value
+			p.pos++
+		}
+		break
+	}
+
+	nodes, _, err := p.parseNodes()
+	if err != nil {
+		return parsedTemplate{}, err
+	}
+	result.nodes = nodes
+
+	// A template extending another one only provides blocks
+	if result.extends != "" {
+		for _, n := range nodes {
+			if n.kind == textNode && strings.TrimSpace(n.value) == "" || n.kind == blockNode {
+				continue
+			}
+			return parsedTemplate{}, fmt.Errorf("template %s extends %s, everything outside {{#block}} tags is ignored, move it into a block", name, result.extends)
+		}
+	}
+
+	return result, nil
+}
+
+// parser builds the node tree of a template from its tokens
+type parser struct {
+	name   string
+	tokens []token
+	pos    int
+}
+
+// parseNodes parses nodes until one of the closing tokens in until, which it
+// returns along with the nodes. Without closing tokens it parses to the end.
+func (p *parser) parseNodes(until ...tokenKind) ([]node, token, error) {
+	var nodes []node
+
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		p.pos++
+
+		switch tok.kind {
+		case textToken:
+			nodes = append(nodes, node{kind: textNode, value: tok.value})
+
+		case variableToken:
+			nodes = append(nodes, node{kind: variableNode, value: tok.value})
+
+		case partialToken:
+			nodes = append(nodes, node{
+				kind:       partialNode,
+				value:      tok.value,
+				standalone: tok.standalone,
+				indent:     tok.indent,
+			})
+
+		case ifToken:
+			children, end, err := p.parseNodes(elseToken, endIfToken)
+			if err != nil {
+				return nil, token{}, err
+			}
+			if end.tag == "" {
+				return nil, token{}, fmt.Errorf("template %s: %s is never closed with {{/if}}", p.name, tok.tag)
+			}
+
+			n := node{kind: ifNode, value: tok.value, children: children}
+			if end.kind == elseToken {
+				n.others, end, err = p.parseNodes(endIfToken)
+				if err != nil {
+					return nil, token{}, err
+				}
+				if end.tag == "" {
+					return nil, token{}, fmt.Errorf("template %s: %s is never closed with {{/if}}", p.name, tok.tag)
+				}
+			}
+			nodes = append(nodes, n)
+
+		case blockToken:
+			children, end, err := p.parseNodes(endBlockToken)
+			if err != nil {
+				return nil, token{}, err
+			}
+			if end.tag == "" {
+				return nil, token{}, fmt.Errorf("template %s: %s is never closed with {{/block}}", p.name, tok.tag)
+			}
+			nodes = append(nodes, node{kind: blockNode, value: tok.value, children: children})
+
+		default:
+			for _, kind := range until {
+				if tok.kind == kind {
+					return nodes, tok, nil
+				}
+			}
+			return nil, token{}, fmt.Errorf("template %s: unexpected %s", p.name, tok.tag)
+		}
+	}
+
+	return nodes, token{}, nil
+}
+
+// references returns the templates a parsed template includes or extends
+func (t parsedTemplate) references() []string {
+	var refs []string
+	if t.extends != "" {
+		refs = append(refs, t.extends)
+	}
+
+	var walk func(nodes []node)
+	walk = func(nodes []node) {
+		for _, n := range nodes {
+			if n.kind == partialNode {
+				refs = append(refs, partialName(n.value))
+			}
+			walk(n.children)
+			walk(n.others)
+		}
+	}
+	walk(t.nodes)
+
+	return refs
+}
+
+// partialName returns the template name of a partial
+func partialName(name string) string {
+	return "partials/" + name
+}
+
+// renderer renders a template together with the templates it refers to
+type renderer struct {
+	templates map[string]parsedTemplate
+	vars      map[string]string
+	// Values of the placeholders written instead of variables, nil to write
+	// the values themselves
+	placeholders *[]string
+}
+
+// placeholderPattern matches the placeholders written instead of variables
+var placeholderPattern = regexp.MustCompile(`__slark_var_([0-9]+)__`)
+
+// placeholder returns the placeholder of the n-th variable written
+func placeholder(n int) string {
+	return fmt.Sprintf("__slark_var_%d__", n)
+}
+
+// render writes the named template to b. blocks replace the blocks of the
+// same name, they come from the templates extending this one.
+func (r *renderer) render(b *strings.Builder, name string, blocks map[string][]node) error {
+	tmpl := r.templates[name]
+
+	if tmpl.extends != "" {
+		// Blocks of the most derived template win
+		merged := make(map[string][]node, len(blocks))
+		for _, n := range tmpl.nodes {
+			if n.kind == blockNode {
+				merged[n.value] = n.children
+			}
+		}
+		for block, nodes := range blocks {
+			merged[block] = nodes
+		}
+
+		return r.render(b, tmpl.extends, merged)
+	}
+
+	return r.renderNodes(b, name, tmpl.nodes, blocks)
+}
+
+// renderNodes writes nodes of the named template to b
+func (r *renderer) renderNodes(b *strings.Builder, name string, nodes []node, blocks map[string][]node) error {
+	for _, n := range nodes {
+		switch n.kind {
+		case textNode:
+			b.WriteString(n.value)
+
+		case variableNode:
+			value, ok := r.vars[n.value]
+			if !ok {
+				return fmt.Errorf("template %s uses undefined variable %s", name, n.value)
+			}
+			if r.placeholders != nil {
+				value = placeholder(len(*r.placeholders))
+				*r.placeholders = append(*r.placeholders, r.vars[n.value])
+			}
+			b.WriteString(value)
+
+		case partialNode:
+			var partial strings.Builder
+			if err := r.render(&partial, partialName(n.value), nil); err != nil {
+				return err
+			}
+
+			content := partial.String()
+			if n.standalone {
+				content = indentLines(content, n.indent)
+				if !strings.HasSuffix(content, "\n") {
+					content += "\n"
+				}
+			}
+			b.WriteString(content)
+
+		case ifNode:
+			value := r.vars[n.value]
+			branch := n.others
+			if value != "" && value != "false" {
+				branch = n.children
+			}
+			if err := r.renderNodes(b, name, branch, blocks); err != nil {
+				return err
+			}
+
+		case blockNode:
+			content := n.children
+			if override, ok := blocks[n.value]; ok {
+				content = override
+			}
+			if err := r.renderNodes(b, name, content, blocks); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// indentLines prefixes every non-empty line of text with indent
+func indentLines(text, indent string) string {
+	if indent == "" {
+		return text
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,472 @@
+// Package template loads workflow templates from the project, the user config
+// directory and the built-in library, and renders them
+package template
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"slark/internal/actions"
+	"slark/internal/models"
+	"slark/templates"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Sources a template can come from, in lookup order
+const (
+	SourceProject = "project"
+	SourceUser    = "user"
+	SourceBuiltin = "built-in"
+)
+
+// extensions are the file extensions of workflow templates, in lookup order
+var extensions = []string{".yml", ".yaml"}
+
+// ValuesSuffix ends the name of sample values files kept next to templates,
+// they are not templates themselves
+const ValuesSuffix = ".values.yaml"
+
+// variablePattern matches valid variable names
+var variablePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// frontMatterDelimiter opens and closes the metadata block of a template
+const frontMatterDelimiter = "---\n"
+
+// Template is a workflow template found in one of the library layers
+type Template struct {
+	Name    string // Path without extension, e.g. vercel/basic
+	Source  string // Layer the template was found in
+	File    string // File the template was read from, empty for built-ins
+	Content string // Raw file content including the front-matter
+	Body    string // Content after the front-matter, the part that is rendered
+	Meta    models.TemplateMeta
+}
+
+// layer is a directory of templates searched by the library
+type layer struct {
+	source string
+	dir    string // Directory on disk, empty for built-ins
+	fsys   fs.FS
+}
+
+// Library looks up templates in the project, then in the user config
+// directory and finally in the templates built into slark. A template in an
+// earlier layer overrides the built-in template with the same name.
+type Library struct {
+	layers []layer
+}
+
+// NewLibrary returns the template library used for the project at projectPath
+func NewLibrary(projectPath string) *Library {
+	var layers []layer
+
+	if projectPath != "" {
+		dir := ProjectDir(projectPath)
+		layers = append(layers, layer{source: SourceProject, dir: dir, fsys: os.DirFS(dir)})
+	}
+
+	if dir := UserDir(); dir != "" {
+		layers = append(layers, layer{source: SourceUser, dir: dir, fsys: os.DirFS(dir)})
+	}
+
+	layers = append(layers, layer{source: SourceBuiltin, fsys: templates.FS})
+
+	return &Library{layers: layers}
+}
+
+// ProjectDir returns the directory of the templates kept in a project
+func ProjectDir(projectPath string) string {
+	return filepath.Join(projectPath, ".slark", "templates")
+}
+
+// UserDir returns $XDG_CONFIG_HOME/slark/templates, falling back to
+// ~/.config when XDG_CONFIG_HOME is not set
+func UserDir() string {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(configDir, "slark", "templates")
+}
+
+// Load returns the template with the given name from the first layer that
+// has it. The name is the template path, the extension is optional.
+func (l *Library) Load(name string) (Template, error) {
+	name = trimExtension(name)
+	if !fs.ValidPath(name) || name == "." {
+		return Template{}, fmt.Errorf("invalid template name: %s", name)
+	}
+
+	for _, layer := range l.layers {
+		for _, ext := range extensions {
+			data, err := fs.ReadFile(layer.fsys, name+ext)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return Template{}, fmt.Errorf("failed to read template %s: %w", name, err)
+			}
+
+			tmpl := Template{Name: name, Source: layer.source, Content: string(data)}
+			if layer.dir != "" {
+				tmpl.File = filepath.Join(layer.dir, filepath.FromSlash(name+ext))
+			}
+
+			tmpl.Meta, tmpl.Body, err = parseFrontMatter(tmpl.Content)
+			if err != nil {
+				return Template{}, fmt.Errorf("invalid front-matter in template %s: %w", name, err)
+			}
+			return tmpl, nil
+		}
+	}
+
+	return Template{}, fmt.Errorf("template %s not found", name)
+}
+
+// List returns every template in the library sorted by name, with their
+// metadata but without their content. Overridden templates are only listed
+// once, from the layer that wins.
+func (l *Library) List() ([]Template, error) {
+	seen := make(map[string]bool)
+	var list []Template
+
+	for _, layer := range l.layers {
+		err := fs.WalkDir(layer.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !slices.Contains(extensions, path.Ext(p)) || strings.HasSuffix(p, ValuesSuffix) {
+				return nil
+			}
+
+			name := trimExtension(p)
+			if seen[name] {
+				return nil
+			}
+			seen[name] = true
+
+			data, err := fs.ReadFile(layer.fsys, p)
+			if err != nil {
+				return err
+			}
+
+			tmpl := Template{Name: name, Source: layer.source}
+			if layer.dir != "" {
+				tmpl.File = filepath.Join(layer.dir, filepath.FromSlash(p))
+			}
+
+			tmpl.Meta, _, err = parseFrontMatter(string(data))
+			if err != nil {
+				return fmt.Errorf("invalid front-matter in template %s: %w", name, err)
+			}
+
+			list = append(list, tmpl)
+			return nil
+		})
+
+		// Override directories are optional
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s templates: %w", layer.source, err)
+		}
+	}
+
+	slices.SortFunc(list, func(a, b Template) int { return strings.Compare(a.Name, b.Name) })
+
+	return list, nil
+}
+
+// Render loads a workflow template with the templates it extends and
+// includes, and renders it. vars are the values slark computes for the
+// project, values are set by the user and must be variables the templates
+// declare. Declared defaults fill in whatever is left, the first declaration
+// of a variable wins.
+//
+// Variables are only filled in after the rendered YAML is parsed, so their
+// values can never change the structure of the workflow.
+func (l *Library) Render(name string, vars, values map[string]string) (*actions.Workflow, error) {
+	name = trimExtension(name)
+	r := &renderer{templates: make(map[string]parsedTemplate), placeholders: &[]string{}}
+
+	var declared []models.TemplateVariable
+	if err := l.collect(r, name, &declared, nil); err != nil {
+		return nil, err
+	}
+
+	if err := validateValues(name, declared, vars, values); err != nil {
+		return nil, err
+	}
+
+	r.vars = make(map[string]string, len(vars)+len(values))
+	for variable, value := range values {
+		r.vars[variable] = value
+	}
+	for variable, value := range vars {
+		r.vars[variable] = value
+	}
+
+	if err := resolveVars(name, declared, r.vars); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	if err := r.render(&b, name, nil); err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(b.String()), &doc); err != nil {
+		return nil, fmt.Errorf("template %s does not render valid YAML: %w", name, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("template %s renders an empty workflow", name)
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("template %s does not render a workflow, partials are only rendered by the templates including them", name)
+	}
+	fillPlaceholders(&doc, *r.placeholders)
+
+	workflow := &actions.Workflow{}
+	if err := doc.Decode(workflow); err != nil {
+		return nil, fmt.Errorf("template %s does not render a valid workflow: %w", name, err)
+	}
+
+	return workflow, nil
+}
+
+// RenderFile renders a workflow template like Render and encodes the workflow
+func (l *Library) RenderFile(name string, vars, values map[string]string) (string, error) {
+	workflow, err := l.Render(name, vars, values)
+	if err != nil {
+		return "", err
+	}
+
+	return workflow.Marshal()
+}
+
+// fillPlaceholders replaces the variable placeholders in the scalars of a
+// parsed template with their values. Scalars holding a variable are strings,
+// whatever their value looks like.
+func fillPlaceholders(node *yaml.Node, values []string) {
+	if node.Kind == yaml.ScalarNode && placeholderPattern.MatchString(node.Value) {
+		node.Value = placeholderPattern.ReplaceAllStringFunc(node.Value, func(match string) string {
+			n, _ := strconv.Atoi(placeholderPattern.FindStringSubmatch(match)[1])
+			return values[n]
+		})
+		node.Tag = "!!str"
+	}
+
+	for _, child := range node.Content {
+		fillPlaceholders(child, values)
+	}
+}
+
+// Variables returns the variables a template can be rendered with, including
+// those declared by the templates it extends and includes
+func (l *Library) Variables(name string) ([]models.TemplateVariable, error) {
+	r := &renderer{templates: make(map[string]parsedTemplate)}
+
+	var declared []models.TemplateVariable
+	if err := l.collect(r, trimExtension(name), &declared, nil); err != nil {
+		return nil, err
+	}
+
+	return declared, nil
+}
+
+// Blocks returns the names of the blocks a template can replace, those of
+// the templates it extends
+func (l *Library) Blocks(name string) ([]string, error) {
+	r := &renderer{templates: make(map[string]parsedTemplate)}
+
+	var declared []models.TemplateVariable
+	if err := l.collect(r, trimExtension(name), &declared, nil); err != nil {
+		return nil, err
+	}
+
+	var blocks []string
+	for base := r.templates[trimExtension(name)].extends; base != ""; base = r.templates[base].extends {
+		for _, n := range r.templates[base].nodes {
+			if n.kind == blockNode && !slices.Contains(blocks, n.value) {
+				blocks = append(blocks, n.value)
+			}
+		}
+	}
+
+	return blocks, nil
+}
+
+// Frameworks returns the frameworks a template supports, empty for any. A
+// template extending another one narrows the frameworks of the extended
+// template, or supports the same ones when it lists none.
+func (l *Library) Frameworks(name string) ([]string, error) {
+	name = trimExtension(name)
+	r := &renderer{templates: make(map[string]parsedTemplate)}
+
+	var declared []models.TemplateVariable
+	if err := l.collect(r, name, &declared, nil); err != nil {
+		return nil, err
+	}
+
+	var frameworks []string
+	for current := name; current != ""; current = r.templates[current].extends {
+		tmpl, err := l.Load(current)
+		if err != nil {
+			return nil, err
+		}
+
+		supported := tmpl.Meta.Frameworks
+		switch {
+		case len(supported) == 0:
+		case len(frameworks) == 0:
+			frameworks = supported
+		default:
+			narrowed := slices.DeleteFunc(slices.Clone(frameworks), func(f string) bool { return !slices.Contains(supported, f) })
+			if len(narrowed) == 0 {
+				return nil, fmt.Errorf("template %s supports %s, none of which %s supports", name, strings.Join(frameworks, ", "), current)
+			}
+			frameworks = narrowed
+		}
+	}
+
+	return frameworks, nil
+}
+
+// collect parses the named template and every template it extends or
+// includes, gathering their declared variables. stack holds the templates
+// being collected to catch templates including themselves.
+func (l *Library) collect(r *renderer, name string, declared *[]models.TemplateVariable, stack []string) error {
+	if slices.Contains(stack, name) {
+		return fmt.Errorf("template %s includes itself: %s", name, strings.Join(append(stack, name), " -> "))
+	}
+	if _, ok := r.templates[name]; ok {
+		return nil
+	}
+
+	tmpl, err := l.Load(name)
+	if err != nil {
+		if len(stack) > 0 {
+			return fmt.Errorf("%s: %w", stack[len(stack)-1], err)
+		}
+		return err
+	}
+
+	parsed, err := parse(name, tmpl.Body)
+	if err != nil {
+		return err
+	}
+	r.templates[name] = parsed
+
+	for _, variable := range tmpl.Meta.Variables {
+		known := slices.ContainsFunc(*declared, func(v models.TemplateVariable) bool { return v.Name == variable.Name })
+		if !known {
+			*declared = append(*declared, variable)
+		}
+	}
+
+	for _, ref := range parsed.references() {
+		if err := l.collect(r, ref, declared, append(stack, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateValues checks user supplied values against the declared variables
+func validateValues(name string, declared []models.TemplateVariable, vars, values map[string]string) error {
+	for variable, value := range values {
+		if _, ok := vars[variable]; ok {
+			return fmt.Errorf("variable %s is set by slark from the project settings and cannot be overridden", variable)
+		}
+
+		known := slices.ContainsFunc(declared, func(v models.TemplateVariable) bool { return v.Name == variable })
+		if !known {
+			return fmt.Errorf("template %s does not declare variable %s, see `slark templates show %s`", name, variable, name)
+		}
+
+		// Values end up in single YAML lines and in the workflow header
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("value of variable %s must be a single line", variable)
+		}
+	}
+
+	return nil
+}
+
+// resolveVars completes vars with the declared defaults. It fails when a
+// required variable has no value.
+func resolveVars(name string, declared []models.TemplateVariable, vars map[string]string) error {
+	var missing []string
+	for _, variable := range declared {
+		if vars[variable.Name] != "" {
+			continue
+		}
+
+		switch {
+		case variable.Default != "":
+			vars[variable.Name] = variable.Default
+		case variable.Required:
+			missing = append(missing, variable.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("template %s is missing required variables: %s", name, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// parseFrontMatter splits a template into its metadata block, delimited by
+// --- lines, and the body. Templates without a block have empty metadata.
+func parseFrontMatter(content string) (models.TemplateMeta, string, error) {
+	var meta models.TemplateMeta
+
+	if !strings.HasPrefix(content, frontMatterDelimiter) {
+		return meta, content, nil
+	}
+
+	block, body, found := strings.Cut(content[len(frontMatterDelimiter):], "\n"+frontMatterDelimiter)
+	if !found {
+		return meta, "", fmt.Errorf("front-matter is not closed with ---")
+	}
+
+	if err := yaml.Unmarshal([]byte(block), &meta); err != nil {
+		return meta, "", err
+	}
+
+	for _, variable := range meta.Variables {
+		if !variablePattern.MatchString(variable.Name) {
+			return meta, "", fmt.Errorf("invalid variable name %q", variable.Name)
+		}
+	}
+
+	return meta, body, nil
+}
+
+// trimExtension removes a template file extension from name
+func trimExtension(name string) string {
+	for _, ext := range extensions {
+		if strings.HasSuffix(name, ext) {
+			return strings.TrimSuffix(name, ext)
+		}
+	}
+	return name
+}
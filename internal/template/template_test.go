@@ -0,0 +1,279 @@
+package template
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"slark/internal/models"
+)
+
+// memoryLibrary returns a library of the given template files
+func memoryLibrary(files map[string]string) *Library {
+	fsys := fstest.MapFS{}
+	for path, content := range files {
+		fsys[path] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return &Library{layers: []layer{{source: SourceProject, fsys: fsys}}}
+}
+
+// renderText renders a template of an in-memory library to text, without
+// parsing the result as a workflow
+func renderText(files map[string]string, name string, vars map[string]string) (string, error) {
+	lib := memoryLibrary(files)
+
+	r := &renderer{templates: make(map[string]parsedTemplate), vars: vars}
+	var declared []models.TemplateVariable
+	if err := lib.collect(r, name, &declared, nil); err != nil {
+		return "", err
+	}
+	if err := resolveVars(name, declared, r.vars); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := r.render(&b, name, nil); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func TestRender(t *testing.T) {
+	layout := "name: {{name}}\n{{#block steps}}\nsteps: default\n{{/block}}\n{{#block env}}\nenv: default\n{{/block}}\n"
+
+	tests := []struct {
+		desc  string
+		files map[string]string
+		want  string
+	}{
+		{
+			"variable",
+			map[string]string{"main.yml": "name: {{name}}\n"},
+			"name: app\n",
+		},
+		{
+			"github expression",
+			map[string]string{"main.yml": "token: ${{ secrets.TOKEN }}\nname: ${{name}}\n"},
+			"token: ${{ secrets.TOKEN }}\nname: ${{name}}\n",
+		},
+		{
+			"declared default",
+			map[string]string{"main.yml": "---\nvariables:\n  - name: node\n    default: \"22\"\n---\nnode: {{node}}\n"},
+			"node: 22\n",
+		},
+		{
+			"if",
+			map[string]string{"main.yml": "{{#if name}}\nset: true\n{{else}}\nset: false\n{{/if}}\n{{#if missing}}\nmissing: true\n{{else}}\nmissing: false\n{{/if}}\n"},
+			"set: true\nmissing: false\n",
+		},
+		{
+			"inline partial",
+			map[string]string{"main.yml": "name: {{> name}}\n", "partials/name.yml": "{{name}}"},
+			"name: app\n",
+		},
+		{
+			"standalone partial is indented",
+			map[string]string{
+				"main.yml":           "steps:\n  {{> steps}}\nend: true\n",
+				"partials/steps.yml": "- run: build {{name}}\n- run: test",
+			},
+			"steps:\n  - run: build app\n  - run: test\nend: true\n",
+		},
+		{
+			"partial in a folder including a partial",
+			map[string]string{
+				"main.yml":                "jobs:\n  {{> node/setup}}\n",
+				"partials/node/setup.yml": "setup:\n  {{> node/cache}}\n",
+				"partials/node/cache.yml": "cache: npm\n",
+			},
+			"jobs:\n  setup:\n    cache: npm\n",
+		},
+		{
+			"partial declares variables",
+			map[string]string{
+				"main.yml":          "{{> node}}\n",
+				"partials/node.yml": "---\nvariables:\n  - name: node\n    default: \"20\"\n---\nnode: {{node}}\n",
+			},
+			"node: 20\n",
+		},
+		{
+			"layout blocks",
+			map[string]string{"main.yml": "{{extends layout}}\n", "layout.yml": layout},
+			"name: app\nsteps: default\nenv: default\n",
+		},
+		{
+			"block override",
+			map[string]string{
+				"main.yml":           "{{extends layout}}\n\n{{#block steps}}\nsteps: {{> steps}}\n{{/block}}\n",
+				"layout.yml":         layout,
+				"partials/steps.yml": "custom",
+			},
+			"name: app\nsteps: custom\nenv: default\n",
+		},
+		{
+			"most derived block wins",
+			map[string]string{
+				"main.yml":   "{{extends middle}}\n{{#block steps}}\nsteps: main\n{{/block}}\n",
+				"middle.yml": "{{extends layout}}\n{{#block steps}}\nsteps: middle\n{{/block}}\n{{#block env}}\nenv: middle\n{{/block}}\n",
+				"layout.yml": layout,
+			},
+			"name: app\nsteps: main\nenv: middle\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := renderText(tt.files, "main", map[string]string{"name": "app"})
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("render = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		desc  string
+		files map[string]string
+		want  string
+	}{
+		{
+			"missing template",
+			map[string]string{},
+			"template main not found",
+		},
+		{
+			"missing partial",
+			map[string]string{"main.yml": "{{> steps}}\n"},
+			"main: template partials/steps not found",
+		},
+		{
+			"missing layout",
+			map[string]string{"main.yml": "{{extends layout}}\n"},
+			"main: template layout not found",
+		},
+		{
+			"extends itself",
+			map[string]string{"main.yml": "{{extends main}}\n"},
+			"template main includes itself: main -> main",
+		},
+		{
+			"extends cycle",
+			map[string]string{"main.yml": "{{extends a}}\n", "a.yml": "{{extends b}}\n", "b.yml": "{{extends a}}\n"},
+			"template a includes itself: main -> a -> b -> a",
+		},
+		{
+			"partial cycle",
+			map[string]string{"main.yml": "{{> a}}\n", "partials/a.yml": "{{> b}}\n", "partials/b.yml": "{{> a}}\n"},
+			"template partials/a includes itself: main -> partials/a -> partials/b -> partials/a",
+		},
+		{
+			"content outside blocks",
+			map[string]string{"main.yml": "{{extends layout}}\nname: main\n", "layout.yml": "name: layout\n"},
+			"everything outside {{#block}} tags is ignored",
+		},
+		{
+			"unclosed if",
+			map[string]string{"main.yml": "{{#if name}}\nname: {{name}}\n"},
+			"{{#if name}} is never closed with {{/if}}",
+		},
+		{
+			"unclosed block",
+			map[string]string{"main.yml": "{{#block steps}}\n"},
+			"{{#block steps}} is never closed with {{/block}}",
+		},
+		{
+			"unexpected tag",
+			map[string]string{"main.yml": "{{/block}}\n"},
+			"unexpected {{/block}}",
+		},
+		{
+			"undefined variable",
+			map[string]string{"main.yml": "node: {{node}}\n"},
+			"template main uses undefined variable node",
+		},
+		{
+			"missing required variable",
+			map[string]string{"main.yml": "---\nvariables:\n  - name: node\n    required: true\n---\nnode: {{node}}\n"},
+			"template main is missing required variables: node",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := renderText(tt.files, "main", map[string]string{"name": "app"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("render error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrameworks(t *testing.T) {
+	layout := "---\nframeworks: [nextjs, vite, astro]\n---\nname: layout\n"
+
+	tests := []struct {
+		desc  string
+		files map[string]string
+		want  []string
+		err   bool
+	}{
+		{"any", map[string]string{"main.yml": "name: main\n"}, nil, false},
+		{"own list", map[string]string{"main.yml": "---\nframeworks: [vite]\n---\nname: main\n"}, []string{"vite"}, false},
+		{
+			"inherited from the layout",
+			map[string]string{"main.yml": "{{extends layout}}\n", "layout.yml": layout},
+			[]string{"nextjs", "vite", "astro"}, false,
+		},
+		{
+			"narrowed by the child",
+			map[string]string{"main.yml": "---\nframeworks: [astro, vite, remix]\n---\n{{extends layout}}\n", "layout.yml": layout},
+			[]string{"astro", "vite"}, false,
+		},
+		{
+			"layout supports any",
+			map[string]string{"main.yml": "---\nframeworks: [vite]\n---\n{{extends layout}}\n", "layout.yml": "name: layout\n"},
+			[]string{"vite"}, false,
+		},
+		{
+			"narrowed along the chain",
+			map[string]string{
+				"main.yml":   "{{extends middle}}\n",
+				"middle.yml": "---\nframeworks: [vite, astro]\n---\n{{extends layout}}\n",
+				"layout.yml": layout,
+			},
+			[]string{"vite", "astro"}, false,
+		},
+		{
+			"nothing in common",
+			map[string]string{"main.yml": "---\nframeworks: [remix]\n---\n{{extends layout}}\n", "layout.yml": layout},
+			nil, true,
+		},
+		{
+			"partials do not count",
+			map[string]string{"main.yml": "{{> steps}}\n", "partials/steps.yml": "---\nframeworks: [vite]\n---\nname: steps\n"},
+			nil, false,
+		},
+		{
+			"extends cycle",
+			map[string]string{"main.yml": "{{extends main}}\n"},
+			nil, true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := memoryLibrary(tt.files).Frameworks("main")
+			if (err != nil) != tt.err {
+				t.Fatalf("Frameworks error = %v, want error %t", err, tt.err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Frameworks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
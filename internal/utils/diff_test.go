@@ -0,0 +1,44 @@
+package utils
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		desc string
+		from string
+		to   string
+		want string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"changed line", "a\nb\nc\n", "a\nB\nc\n", "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{"insertion at the start", "a\nb\n", "x\na\nb\n", "@@ -1,2 +1,3 @@\n+x\n a\n b\n"},
+		{"insertion at the end", "a\nb\n", "a\nb\nc\n", "@@ -1,2 +1,3 @@\n a\n b\n+c\n"},
+		{"new file", "", "a\n", "@@ -0,0 +1 @@\n+a\n"},
+		{"removed file", "a\nb\n", "", "@@ -1,2 +0,0 @@\n-a\n-b\n"},
+		{"missing final newline", "a\n", "a", "@@ -1 +1 @@\n-a\n+a\n\\ No newline at end of file\n"},
+		{
+			"context is limited", "1\n2\n3\n4\n5\n6\n7\n", "1\n2\n3\nX\n5\n6\n7\n",
+			"@@ -1,7 +1,7 @@\n 1\n 2\n 3\n-4\n+X\n 5\n 6\n 7\n",
+		},
+		{
+			"close changes share a hunk", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", "A\n2\n3\n4\nE\n6\n7\n8\n9\n10\n",
+			"@@ -1,8 +1,8 @@\n-1\n+A\n 2\n 3\n 4\n-5\n+E\n 6\n 7\n 8\n",
+		},
+		{
+			"distant changes get their own hunk", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", "A\n2\n3\n4\n5\n6\n7\n8\n9\nJ\n",
+			"@@ -1,4 +1,4 @@\n-1\n+A\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+J\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			want := tt.want
+			if want != "" {
+				want = "--- a/file\n+++ b/file\n" + want
+			}
+			if got := UnifiedDiff("a/file", "b/file", tt.from, tt.to); got != want {
+				t.Errorf("UnifiedDiff = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -6,11 +6,12 @@ import (
 	"strings"
 )
 
-// GetGitHubRepoInfo retrieves the current GitHub repository information
+// GetGitHubRepoInfo retrieves the GitHub repository information of the repository in dir
 // Returns the repository in "username/repo" format
-func GetGitHubRepoInfo() (string, error) {
+func GetGitHubRepoInfo(dir string) (string, error) {
 	// Get the remote URL
 	cmd := exec.Command("git", "config", "--get", "remote.origin.url")
+	cmd.Dir = dir
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to get git remote: %w", err)
@@ -39,9 +40,10 @@ func GetGitHubRepoInfo() (string, error) {
 	return repo, nil
 }
 
-// GetCurrentBranch returns the name of the current git branch
-func GetCurrentBranch() (string, error) {
+// GetCurrentBranch returns the name of the current git branch of the repository in dir
+func GetCurrentBranch(dir string) (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = dir
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to get current branch: %w", err)
@@ -50,9 +52,10 @@ func GetCurrentBranch() (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
-// IsGitRepository checks if the current directory is a git repository
-func IsGitRepository() bool {
+// IsGitRepository checks if dir is inside a git repository
+func IsGitRepository(dir string) bool {
 	cmd := exec.Command("git", "rev-parse", "--is-inside-work-tree")
+	cmd.Dir = dir
 	if err := cmd.Run(); err != nil {
 		return false
 	}
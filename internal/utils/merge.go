@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+)
+
+// Conflict markers written around regions both sides changed differently
+const (
+	ConflictStart  = "<<<<<<< local"
+	ConflictMiddle = "======="
+	ConflictEnd    = ">>>>>>> slark"
+)
+
+// Merge3 performs a line based three-way merge. base is the common ancestor,
+// ours the locally edited version and theirs the newly generated one.
+// It returns the merged text and the number of conflicting regions, which are
+// wrapped in git style conflict markers.
+func Merge3(base, ours, theirs string) (string, int) {
+	baseLines := splitLines(base)
+	ourLines := splitLines(ours)
+	theirLines := splitLines(theirs)
+
+	toOurs := matchLines(baseLines, ourLines)
+	toTheirs := matchLines(baseLines, theirLines)
+
+	var b strings.Builder
+	conflicts := 0
+
+	i, o, t := 0, 0, 0
+	for {
+		// Find the next base line that is unchanged on both sides
+		j := i
+		for j < len(baseLines) && (toOurs[j] < 0 || toTheirs[j] < 0) {
+			j++
+		}
+
+		oEnd, tEnd := len(ourLines), len(theirLines)
+		if j < len(baseLines) {
+			oEnd, tEnd = toOurs[j], toTheirs[j]
+		}
+
+		// Resolve the changed chunk between the stable lines
+		if !mergeChunk(&b, baseLines[i:j], ourLines[o:oEnd], theirLines[t:tEnd]) {
+			conflicts++
+		}
+
+		if j == len(baseLines) {
+			break
+		}
+
+		b.WriteString(baseLines[j])
+		i, o, t = j+1, oEnd+1, tEnd+1
+	}
+
+	return b.String(), conflicts
+}
+
+// mergeChunk writes the resolution of a single chunk and reports whether it
+// could be merged cleanly
+func mergeChunk(b *strings.Builder, base, ours, theirs []string) bool {
+	switch {
+	case slices.Equal(ours, base):
+		writeLines(b, theirs)
+	case slices.Equal(theirs, base), slices.Equal(ours, theirs):
+		writeLines(b, ours)
+	default:
+		b.WriteString(ConflictStart + "\n")
+		writeLines(b, ours)
+		terminateLine(b)
+		b.WriteString(ConflictMiddle + "\n")
+		writeLines(b, theirs)
+		terminateLine(b)
+		b.WriteString(ConflictEnd + "\n")
+		return false
+	}
+	return true
+}
+
+// matchLines maps every line of a to the index of the line it is paired with
+// in b by their longest common subsequence, or -1 when it has no partner
+func matchLines(a, b []string) []int {
+	lcs := lcsTable(a, b)
+
+	match := make([]int, len(a))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			match[i] = j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			match[i] = -1
+			i++
+		default:
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		match[i] = -1
+	}
+
+	return match
+}
+
+func writeLines(b *strings.Builder, lines []string) {
+	for _, line := range lines {
+		b.WriteString(line)
+	}
+}
+
+// terminateLine makes sure the builder ends with a newline before a marker
+func terminateLine(b *strings.Builder) {
+	if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+		b.WriteString("\n")
+	}
+}
@@ -0,0 +1,59 @@
+package utils
+
+import "testing"
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		desc      string
+		base      string
+		ours      string
+		theirs    string
+		want      string
+		conflicts int
+	}{
+		{"unchanged", "a\nb\n", "a\nb\n", "a\nb\n", "a\nb\n", 0},
+		{"only theirs changed", "a\nb\nc\n", "a\nb\nc\n", "a\nB\nc\n", "a\nB\nc\n", 0},
+		{"only ours changed", "a\nb\nc\n", "a\nB\nc\n", "a\nb\nc\n", "a\nB\nc\n", 0},
+		{"same change on both sides", "a\nb\nc\n", "a\nB\nc\n", "a\nB\nc\n", "a\nB\nc\n", 0},
+		{"changes to different lines", "a\nb\nc\nd\ne\n", "A\nb\nc\nd\ne\n", "a\nb\nc\nd\nE\n", "A\nb\nc\nd\nE\n", 0},
+		{"removed and changed lines", "a\nb\nc\nd\ne\n", "a\nc\nd\ne\n", "a\nb\nc\nd\nE\n", "a\nc\nd\nE\n", 0},
+		{"theirs inserts at the start", "a\nb\n", "a\nB\n", "h\na\nb\n", "h\na\nB\n", 0},
+		{"ours inserts at the start", "a\nb\n", "h\na\nb\n", "a\nB\n", "h\na\nB\n", 0},
+		{"theirs inserts at the end", "a\nb\n", "A\nb\n", "a\nb\nz\n", "A\nb\nz\n", 0},
+		{"ours inserts at the end", "a\nb\n", "a\nb\nz\n", "A\nb\n", "A\nb\nz\n", 0},
+		{"same insertion at the end", "a\n", "a\nz\n", "a\nz\n", "a\nz\n", 0},
+		{
+			"conflicting change", "a\nb\nc\n", "a\nX\nc\n", "a\nY\nc\n",
+			"a\n<<<<<<< local\nX\n=======\nY\n>>>>>>> slark\nc\n", 1,
+		},
+		{
+			"conflicting insertions at the start", "a\n", "x\na\n", "y\na\n",
+			"<<<<<<< local\nx\n=======\ny\n>>>>>>> slark\na\n", 1,
+		},
+		{
+			"conflicting insertions at the end", "a\n", "a\nx\n", "a\ny\n",
+			"a\n<<<<<<< local\nx\n=======\ny\n>>>>>>> slark\n", 1,
+		},
+		{
+			"two conflicts", "a\nb\nc\nd\ne\n", "X\nb\nc\nd\nZ\n", "Y\nb\nc\nd\nW\n",
+			"<<<<<<< local\nX\n=======\nY\n>>>>>>> slark\nb\nc\nd\n<<<<<<< local\nZ\n=======\nW\n>>>>>>> slark\n", 2,
+		},
+		{
+			"conflict without a final newline", "a\nb", "a\nX", "a\nY",
+			"a\n<<<<<<< local\nX\n=======\nY\n>>>>>>> slark\n", 1,
+		},
+		{
+			"edited and removed", "a\nb\nc\n", "a\nB\nc\n", "a\nc\n",
+			"a\n<<<<<<< local\nB\n=======\n>>>>>>> slark\nc\n", 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, conflicts := Merge3(tt.base, tt.ours, tt.theirs)
+			if got != tt.want || conflicts != tt.conflicts {
+				t.Errorf("Merge3 = %q with %d conflicts, want %q with %d", got, conflicts, tt.want, tt.conflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+// Package workspace reads the packages of a JavaScript monorepo, declared in
+// pnpm-workspace.yaml or the workspaces field of package.json, and finds the
+// local packages an app depends on
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Package is a package of the workspace
+type Package struct {
+	Name         string
+	Dir          string   // Directory relative to the workspace root, with forward slashes
+	Dependencies []string // Names of every package the package depends on
+}
+
+// Workspace is the set of packages of a monorepo
+type Workspace struct {
+	Root     string
+	Packages []Package
+}
+
+// manifest is the part of package.json slark reads
+type manifest struct {
+	Name                 string            `json:"name"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	Workspaces           json.RawMessage   `json:"workspaces"`
+}
+
+// Load reads the workspace at root. A repository without workspace
+// configuration is an empty workspace.
+func Load(root string) (*Workspace, error) {
+	patterns, err := workspacePatterns(root)
+	if err != nil {
+		return nil, err
+	}
+
+	ws := &Workspace{Root: root}
+	if len(patterns) == 0 {
+		return ws, nil
+	}
+
+	dirs, err := Glob(root, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range dirs {
+		pkg, err := readPackage(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		ws.Packages = append(ws.Packages, pkg)
+	}
+
+	return ws, nil
+}
+
+// workspacePatterns returns the package globs of pnpm-workspace.yaml, or else
+// those of the workspaces field of the root package.json
+func workspacePatterns(root string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(root, "pnpm-workspace.yaml"))
+	if err == nil {
+		var pnpm struct {
+			Packages []string `yaml:"packages"`
+		}
+		if err := yaml.Unmarshal(data, &pnpm); err != nil {
+			return nil, fmt.Errorf("failed to parse pnpm-workspace.yaml: %w", err)
+		}
+		return pnpm.Packages, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read pnpm-workspace.yaml: %w", err)
+	}
+
+	m, err := readManifest(filepath.Join(root, "package.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Workspaces) == 0 {
+		return nil, nil
+	}
+
+	// npm and yarn take a list, yarn also an object with a packages list
+	var patterns []string
+	if err := json.Unmarshal(m.Workspaces, &patterns); err == nil {
+		return patterns, nil
+	}
+
+	var object struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(m.Workspaces, &object); err != nil {
+		return nil, fmt.Errorf("invalid workspaces field in package.json: %w", err)
+	}
+	return object.Packages, nil
+}
+
+// readManifest reads a package.json file
+func readManifest(file string) (manifest, error) {
+	var m manifest
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return m, err
+	}
+
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	return m, nil
+}
+
+// readPackage reads the package in dir, relative to root
+func readPackage(root, dir string) (Package, error) {
+	m, err := readManifest(filepath.Join(root, filepath.FromSlash(dir), "package.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return Package{}, err
+	}
+
+	pkg := Package{Name: m.Name, Dir: dir}
+	for _, deps := range []map[string]string{m.Dependencies, m.DevDependencies, m.PeerDependencies, m.OptionalDependencies} {
+		for name := range deps {
+			if !slices.Contains(pkg.Dependencies, name) {
+				pkg.Dependencies = append(pkg.Dependencies, name)
+			}
+		}
+	}
+	slices.Sort(pkg.Dependencies)
+
+	return pkg, nil
+}
+
+// Dependencies returns the directories of the workspace packages the package
+// in dir depends on, directly or through other workspace packages, sorted.
+// Dependencies are matched by name, which covers the workspace: protocol of
+// pnpm and yarn as well as plain versions of npm workspaces.
+func (w *Workspace) Dependencies(dir string) ([]string, error) {
+	if len(w.Packages) == 0 {
+		return nil, nil
+	}
+
+	dir = path.Clean(filepath.ToSlash(dir))
+
+	start, err := readPackage(w.Root, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	seen := map[string]bool{start.Name: true}
+	queue := start.Dependencies
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		index := slices.IndexFunc(w.Packages, func(p Package) bool { return p.Name == name })
+		if index < 0 {
+			continue
+		}
+
+		pkg := w.Packages[index]
+		if pkg.Dir != dir {
+			dirs = append(dirs, pkg.Dir)
+		}
+		queue = append(queue, pkg.Dependencies...)
+	}
+
+	slices.Sort(dirs)
+	return dirs, nil
+}
+
+// Glob returns the directories below root matching the patterns that hold a
+// package.json, sorted and relative to root with forward slashes. Patterns
+// use workspace syntax: * matches within a path segment, ** matches any
+// number of segments and a leading ! excludes what the pattern matches.
+func Glob(root string, patterns ...string) ([]string, error) {
+	// Without ** the search stops at the depth of the longest pattern
+	var include, exclude [][]string
+	maxDepth := 0
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = path.Clean(strings.TrimPrefix(strings.TrimPrefix(pattern, "!"), "./"))
+
+		segments := strings.Split(pattern, "/")
+		if negated {
+			exclude = append(exclude, segments)
+		} else {
+			include = append(include, segments)
+			if slices.Contains(segments, "**") {
+				maxDepth = -1
+			} else if maxDepth >= 0 {
+				maxDepth = max(maxDepth, len(segments))
+			}
+		}
+	}
+
+	var dirs []string
+	err := filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+
+		// Installed and hidden directories never hold workspace packages
+		if d.Name() == "node_modules" || strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		segments := strings.Split(filepath.ToSlash(rel), "/")
+		if matchesAny(include, segments) && !matchesAny(exclude, segments) {
+			if _, err := os.Stat(filepath.Join(file, "package.json")); err == nil {
+				dirs = append(dirs, filepath.ToSlash(rel))
+			}
+		}
+
+		if maxDepth >= 0 && len(segments) >= maxDepth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for packages: %w", err)
+	}
+
+	slices.Sort(dirs)
+	return dirs, nil
+}
+
+// matchesAny reports whether the path segments match one of the patterns
+func matchesAny(patterns [][]string, segments []string) bool {
+	return slices.ContainsFunc(patterns, func(pattern []string) bool {
+		return matchSegments(pattern, segments)
+	})
+}
+
+// matchSegments matches path segments against pattern segments, where **
+// matches any number of segments
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	ok, err := path.Match(pattern[0], segments[0])
+	if err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
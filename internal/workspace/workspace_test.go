@@ -0,0 +1,164 @@
+package workspace
+
+import (
+	"slices"
+	"testing"
+
+	"slark/internal/testutil"
+)
+
+// globFixture is a monorepo with apps, packages and folders Glob skips
+var globFixture = map[string]string{
+	"package.json":                           "{}",
+	"apps/web/package.json":                  "{}",
+	"apps/docs/package.json":                 "{}",
+	"apps/web/e2e/package.json":              "{}",
+	"apps/readme/README.md":                  "",
+	"packages/ui/package.json":               "{}",
+	"packages/config/eslint/package.json":    "{}",
+	"packages/config/tsconfig/package.json":  "{}",
+	"apps/web/node_modules/dep/package.json": "{}",
+	"apps/.cache/package.json":               "{}",
+}
+
+func TestGlob(t *testing.T) {
+	tests := []struct {
+		desc     string
+		patterns []string
+		want     []string
+	}{
+		{"single level", []string{"apps/*"}, []string{"apps/docs", "apps/web"}},
+		{"leading dot slash", []string{"./apps/*"}, []string{"apps/docs", "apps/web"}},
+		{"stops at the pattern depth", []string{"packages/*"}, []string{"packages/ui"}},
+		{"two levels", []string{"packages/*/*"}, []string{"packages/config/eslint", "packages/config/tsconfig"}},
+		{
+			"double star", []string{"**"},
+			[]string{"apps/docs", "apps/web", "apps/web/e2e", "packages/config/eslint", "packages/config/tsconfig", "packages/ui"},
+		},
+		{"double star below a folder", []string{"packages/**"}, []string{"packages/config/eslint", "packages/config/tsconfig", "packages/ui"}},
+		{"double star in the middle", []string{"apps/**/e2e"}, []string{"apps/web/e2e"}},
+		{"exclusion", []string{"apps/*", "!apps/docs"}, []string{"apps/web"}},
+		{"exclusion with a star", []string{"packages/**", "!packages/config/*"}, []string{"packages/ui"}},
+		{"several patterns", []string{"apps/web", "packages/*"}, []string{"apps/web", "packages/ui"}},
+		{"no match", []string{"services/*"}, nil},
+	}
+
+	root := t.TempDir()
+	testutil.WriteFiles(t, root, globFixture)
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := Glob(root, tt.patterns...)
+			if err != nil {
+				t.Fatalf("Glob: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Glob(%q) = %q, want %q", tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	packages := map[string]string{
+		"apps/web/package.json":      `{"name": "web"}`,
+		"packages/ui/package.json":   `{"name": "@acme/ui"}`,
+		"tools/scripts/package.json": `{"name": "scripts"}`,
+	}
+
+	tests := []struct {
+		desc  string
+		files map[string]string
+		want  []string
+	}{
+		{"no workspace", map[string]string{"package.json": `{"name": "root"}`}, nil},
+		{"no package.json", nil, nil},
+		{
+			"pnpm workspace",
+			map[string]string{"pnpm-workspace.yaml": "packages:\n  - apps/*\n  - packages/*\n", "package.json": `{"workspaces": ["tools/*"]}`},
+			[]string{"apps/web", "packages/ui"},
+		},
+		{
+			"workspaces list",
+			map[string]string{"package.json": `{"workspaces": ["apps/*", "tools/*"]}`},
+			[]string{"apps/web", "tools/scripts"},
+		},
+		{
+			"workspaces object",
+			map[string]string{"package.json": `{"workspaces": {"packages": ["packages/*"], "nohoist": ["**/react"]}}`},
+			[]string{"packages/ui"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			root := t.TempDir()
+			testutil.WriteFiles(t, root, packages)
+			testutil.WriteFiles(t, root, tt.files)
+
+			ws, err := Load(root)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			var got []string
+			for _, pkg := range ws.Packages {
+				got = append(got, pkg.Dir)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("packages = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadInvalidWorkspaces(t *testing.T) {
+	root := t.TempDir()
+	testutil.WriteFiles(t, root, map[string]string{"package.json": `{"workspaces": "apps/*"}`})
+
+	if _, err := Load(root); err == nil {
+		t.Error("Load succeeded, want an error for a workspaces string")
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	root := t.TempDir()
+	testutil.WriteFiles(t, root, map[string]string{
+		"pnpm-workspace.yaml": "packages:\n  - apps/*\n  - packages/*\n",
+		"apps/web/package.json": `{"name": "web", "dependencies": {"@acme/ui": "workspace:*", "react": "^19.0.0"},
+			"devDependencies": {"@acme/tsconfig": "workspace:*"}}`,
+		"apps/docs/package.json":         `{"name": "docs", "peerDependencies": {"@acme/ui": "1.0.0"}, "optionalDependencies": {"web": "*"}}`,
+		"apps/admin/package.json":        `{"name": "admin", "dependencies": {"react": "^19.0.0"}}`,
+		"packages/ui/package.json":       `{"name": "@acme/ui", "dependencies": {"@acme/tokens": "workspace:^"}}`,
+		"packages/tokens/package.json":   `{"name": "@acme/tokens", "devDependencies": {"@acme/ui": "workspace:*"}}`,
+		"packages/tsconfig/package.json": `{"name": "@acme/tsconfig"}`,
+	})
+
+	ws, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	tests := []struct {
+		dir  string
+		want []string
+	}{
+		{"apps/web", []string{"packages/tokens", "packages/tsconfig", "packages/ui"}},
+		{"./apps/web/", []string{"packages/tokens", "packages/tsconfig", "packages/ui"}},
+		{"apps/docs", []string{"apps/web", "packages/tokens", "packages/tsconfig", "packages/ui"}},
+		{"apps/admin", nil},
+		{"packages/ui", []string{"packages/tokens"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			got, err := ws.Dependencies(tt.dir)
+			if err != nil {
+				t.Fatalf("Dependencies: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Dependencies(%q) = %q, want %q", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
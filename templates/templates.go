@@ -0,0 +1,11 @@
+// Package templates holds the built-in workflow templates shipped with slark
+package templates
+
+import "embed"
+
+// FS contains the built-in templates, organised as <category>/<name>.yml.
+// The platform variants extend layouts/<platform> and share the steps in
+// partials/.
+//
+//go:embed vercel cloudflare notifications layouts partials
+var FS embed.FS